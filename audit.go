@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const defaultAuditLogPath = "~/.kube/updater-audit.log"
+
+// auditEntry is one line of the JSONL audit log.
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Config  string    `json:"config"`
+	Source  string    `json:"source"`
+	Context string    `json:"context,omitempty"`
+	Changes []string  `json:"changes"`
+}
+
+// audit describes the running operation for the audit log. main sets the
+// source to the subcommand name; flows that know better refine it.
+var audit struct {
+	source  string
+	context string
+}
+
+// appendAuditLog records changes applied to the kubeconfig at configPath.
+// Failing to write the log only produces a warning, the kubeconfig has
+// already been written at this point.
+func appendAuditLog(configPath string, changes []string) {
+	if len(changes) == 0 {
+		return
+	}
+	if err := writeAuditEntry(newAuditEntry(configPath, changes)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: writing audit log: %v\n", err)
+	}
+}
+
+// newAuditEntry describes changes applied by the running operation to the
+// kubeconfig at configPath.
+func newAuditEntry(configPath string, changes []string) auditEntry {
+	if abs, err := filepath.Abs(configPath); err == nil {
+		configPath = abs
+	}
+	return auditEntry{
+		Time:    time.Now(),
+		Config:  configPath,
+		Source:  audit.source,
+		Context: audit.context,
+		Changes: changes,
+	}
+}
+
+func writeAuditEntry(entry auditEntry) error {
+	path, err := expandPath(defaultAuditLogPath)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	debugf("appending to audit log %s", path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readAuditLog returns all entries of the audit log, oldest first.
+func readAuditLog() ([]auditEntry, error) {
+	path, err := expandPath(defaultAuditLogPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("parsing audit log %s line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func runHistory(args []string) error {
+	fs, _ := newFlagSet("history")
+	contextFlag := fs.String("context", "", "Only show changes that mention this context, cluster or user")
+	limitFlag := fs.Int("n", 20, "Show at most this many entries (0 for all)")
+	jsonFlag := fs.Bool("json", false, "Print the entries as JSON lines")
+	fs.Parse(args)
+
+	entries, err := readAuditLog()
+	if err != nil {
+		return err
+	}
+	if *contextFlag != "" {
+		var matching []auditEntry
+		for _, entry := range entries {
+			if entry.mentions(*contextFlag) {
+				matching = append(matching, entry)
+			}
+		}
+		entries = matching
+	}
+	if *limitFlag > 0 && len(entries) > *limitFlag {
+		entries = entries[len(entries)-*limitFlag:]
+	}
+
+	if *jsonFlag {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Println("No changes recorded.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Time.Local().Format(time.RFC3339), entry.Source, entry.Config)
+		for _, change := range entry.Changes {
+			fmt.Fprintf(w, "\t- %s\n", change)
+		}
+	}
+	return w.Flush()
+}
+
+// mentions reports whether the entry concerns the named context, cluster or
+// user.
+func (e auditEntry) mentions(name string) bool {
+	if e.Context == name {
+		return true
+	}
+	quoted := fmt.Sprintf("%q", name)
+	for _, change := range e.Changes {
+		if strings.Contains(change, quoted) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// awsSecretFields are the fields of a JSON secret tried in this order when
+// no field is given: the kubeconfig ones first, then a token.
+var awsSecretFields = []string{"kubeconfig", "config", "value", "token"}
+
+// readSSMKubeconfig reads the kubeconfig stored in an SSM Parameter Store
+// parameter, given by name or ARN, with the aws CLI. SecureString
+// parameters are decrypted; credentials and region come from the default
+// AWS credential chain.
+func readSSMKubeconfig(name string) ([]byte, error) {
+	return runTool("aws", "ssm", "get-parameter", "--name", name, "--with-decryption",
+		"--query", "Parameter.Value", "--output", "text")
+}
+
+// readAWSSecretKubeconfig reads a Secrets Manager secret, given by name or
+// ARN, with the aws CLI. A secret holding JSON key/value pairs is reduced to
+// the given field, or the first of awsSecretFields it has. The value is
+// returned as kubeconfig if it is one, and used as token for the user of
+// target otherwise.
+func readAWSSecretKubeconfig(id, field string, cfg *api.Config, target *api.Context) ([]byte, error) {
+	out, err := runTool("aws", "secretsmanager", "get-secret-value", "--secret-id", id,
+		"--query", "SecretString", "--output", "text")
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(out, &fields); err == nil {
+		candidates := awsSecretFields
+		if field != "" {
+			candidates = []string{field}
+		}
+		var value string
+		for _, candidate := range candidates {
+			if v, ok := fields[candidate].(string); ok {
+				debugf("using field %s of secret %s", candidate, id)
+				value = v
+				break
+			}
+		}
+		if value == "" {
+			return nil, fmt.Errorf("secret %s has no %s field, it has %s", id, strings.Join(candidates, " or "), quoteList(sortedNames(fields)))
+		}
+		out = []byte(value)
+	} else if field != "" {
+		return nil, fmt.Errorf("secret %s does not hold JSON fields to take %s from", id, field)
+	}
+	return kubeconfigOrToken(out, cfg, target)
+}
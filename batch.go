@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// batchResult is the outcome of merging one file of a batch.
+type batchResult struct {
+	path      string
+	changes   int
+	conflicts []string
+	err       error
+}
+
+func runBatch(args []string) error {
+	flags, configPathFlag := newFlagSet("batch")
+	tryFlag := flags.Bool("try", false, "Try mode: do not update file, just print the results")
+	globFlag := flags.String("glob", "*", "Only merge files whose name matches this pattern, e.g. '*.yaml'")
+	addStrategyFlag(flags)
+	addRenameSuffixFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one directory")
+	}
+	if _, err := filepath.Match(*globFlag, ""); err != nil {
+		return fmt.Errorf("invalid --glob: %w", err)
+	}
+	dir, err := expandPath(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match, _ := filepath.Match(*globFlag, entry.Name()); match && entry.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing %s: %w", dir, err)
+	}
+
+	var changes []string
+	var results []batchResult
+	for _, path := range paths {
+		result := batchResult{path: path}
+		fileChanges, err := mergeBatchFile(cfg, path, &result.conflicts)
+		if err != nil {
+			result.err = err
+		} else {
+			result.changes = len(fileChanges)
+			changes = append(changes, fileChanges...)
+		}
+		results = append(results, result)
+	}
+
+	printChanges(changes)
+	failed := printBatchResults(results)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+	} else if len(changes) > 0 {
+		audit.source = "batch " + dir
+		if err := saveConfig(configPath, origData, cfg, changes); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files could not be merged", failed, len(results))
+	}
+	return nil
+}
+
+// mergeBatchFile merges the kubeconfig at path into cfg like import does
+// and returns the changes made. Conflicting fields are appended to
+// conflicts.
+func mergeBatchFile(cfg *api.Config, path string, conflicts *[]string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = decodeInput(data, false); err != nil {
+		return nil, err
+	}
+	if err := kubeconfig.Validate(data); err != nil {
+		return nil, err
+	}
+	incoming, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+
+	// Work on a copy, so a file failing half way leaves cfg untouched
+	merged := cfg.DeepCopy()
+	changes, err := dedupeClusters(merged, incoming)
+	if err != nil {
+		return nil, err
+	}
+	renameChanges, err := renameCollisions(merged, incoming)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, renameChanges...)
+	resolve := func(c kubeconfig.FieldChange) (bool, error) {
+		if c.Conflict() {
+			*conflicts = append(*conflicts, fmt.Sprintf("%s: local %s, incoming %s", c, c.Local, c.Incoming))
+		}
+		return resolveConflict(c)
+	}
+	mergeChanges, err := kubeconfig.Merge(merged, incoming, resolve)
+	if err != nil {
+		return nil, err
+	}
+	*cfg = *merged
+	return append(changes, mergeChanges...), nil
+}
+
+// printBatchResults prints the outcome of every file and the conflicts met,
+// and returns the number of files that failed.
+func printBatchResults(results []batchResult) int {
+	infof("\nResults (%d files):\n", len(results))
+	failed := 0
+	var conflicts []string
+	for _, result := range results {
+		switch {
+		case result.err != nil:
+			failed++
+			failf("  ✗ %s: %v\n", result.path, result.err)
+		case result.changes == 0:
+			infof("  = %s: up to date\n", result.path)
+		default:
+			infof("  ✓ %s: %d changes\n", result.path, result.changes)
+		}
+		for _, conflict := range result.conflicts {
+			conflicts = append(conflicts, result.path+": "+conflict)
+		}
+	}
+	if len(conflicts) > 0 {
+		infof("\nConflicts (%d):\n", len(conflicts))
+		for _, conflict := range conflicts {
+			infof("  - %s\n", conflict)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// healthResult is the outcome of probing the cluster of one context.
+type healthResult struct {
+	Context   string `json:"context"`
+	Server    string `json:"server"`
+	Reachable bool   `json:"reachable"`
+	// TLS is "ok", "invalid", "insecure" if verification is disabled, or
+	// "none" for plain http.
+	TLS string `json:"tls"`
+	// Auth is "ok", "failed" or "unknown" if the server was not reached.
+	Auth    string `json:"auth"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (r healthResult) healthy() bool {
+	return r.Reachable && r.TLS != "invalid" && r.Auth == "ok"
+}
+
+func runCheckAll(args []string) error {
+	fs, configPathFlag := newFlagSet("check-all")
+	jsonFlag := fs.Bool("json", false, "Print the results as JSON")
+	workersFlag := fs.Int("workers", 10, "Number of clusters to probe concurrently")
+	timeoutFlag := fs.Duration("timeout", 5*time.Second, "Timeout of each request to an API server")
+	tagFlag := addTagFlag(fs, "Only check contexts with this tag")
+	fs.Parse(args)
+
+	if *workersFlag < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	_, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	filterContexts(cfg, *tagFlag)
+	names := sortedNames(cfg.Contexts)
+	if len(names) == 0 {
+		return fmt.Errorf("no contexts to check")
+	}
+
+	results := make([]healthResult, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(*workersFlag, len(names)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = checkContext(cfg, names[i], *timeoutFlag)
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	unhealthy := 0
+	for _, result := range results {
+		if !result.healthy() {
+			unhealthy++
+		}
+	}
+	if *jsonFlag {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CONTEXT\tSERVER\tREACHABLE\tTLS\tAUTH\tVERSION\tERROR")
+		for _, r := range results {
+			reachable := "no"
+			if r.Reachable {
+				reachable = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Context, r.Server, reachable, r.TLS, r.Auth, r.Version, r.Error)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		infof("%d of %d contexts healthy\n", len(results)-unhealthy, len(results))
+	}
+	if unhealthy > 0 {
+		return fmt.Errorf("%d of %d contexts are unhealthy", unhealthy, len(results))
+	}
+	return nil
+}
+
+// checkContext probes the API server of the named context: /version, which
+// any client may read, tells whether the server is reachable over valid
+// TLS, and /api whether it accepts the context's credentials.
+func checkContext(cfg *api.Config, name string, timeout time.Duration) healthResult {
+	result := healthResult{Context: name, TLS: "ok", Auth: "unknown"}
+	if cluster, exists := cfg.Clusters[cfg.Contexts[name].Cluster]; exists {
+		result.Server = cluster.Server
+		switch {
+		case cluster.InsecureSkipTLSVerify:
+			result.TLS = "insecure"
+		case strings.HasPrefix(cluster.Server, "http://"):
+			result.TLS = "none"
+		}
+	}
+	restCfg, err := restConfigFor(cfg, name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	restCfg.Timeout = timeout
+
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	err = restRequest(restCfg, "GET", "/version", nil, &version)
+	var apiErr *kubeAPIError
+	switch {
+	case err == nil:
+		result.Reachable = true
+		result.Version = version.GitVersion
+	case errors.As(err, &apiErr):
+		// Some clusters hide /version from anonymous clients, but they
+		// answered over TLS
+		result.Reachable = true
+	default:
+		var unknownAuthority x509.UnknownAuthorityError
+		var hostname x509.HostnameError
+		var invalid x509.CertificateInvalidError
+		if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalid) {
+			result.Reachable = true
+			result.TLS = "invalid"
+		}
+		result.Error = err.Error()
+		return result
+	}
+
+	err = restRequest(restCfg, "GET", "/api", nil, nil)
+	switch {
+	case err == nil:
+		result.Auth = "ok"
+	case errors.As(err, &apiErr) && apiErr.statusCode == http.StatusForbidden:
+		// Authenticated, just not allowed to discover APIs
+		result.Auth = "ok"
+	default:
+		result.Auth = "failed"
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const defaultRenameSuffix = "-imported"
+
+var renameSuffixFlag string
+
+// addRenameSuffixFlag registers the --rename-suffix flag on fs.
+func addRenameSuffixFlag(fs *flag.FlagSet) {
+	fs.StringVar(&renameSuffixFlag, "rename-suffix", userSettings.RenameSuffix,
+		"Suffix proposed for incoming entries whose name is taken by a different cluster")
+}
+
+// renameCollisions renames the clusters, users and contexts of incoming
+// whose names already exist in cfg but refer to a different cluster, so
+// merging does not overwrite them. The proposed name carries the rename
+// suffix and can be changed at the prompt; keeping the original name
+// overwrites the existing entry. Without a terminal the proposed name is
+// used.
+func renameCollisions(cfg, incoming *api.Config) ([]string, error) {
+	var changes []string
+	for _, name := range sortedNames(incoming.Clusters) {
+		existing, exists := cfg.Clusters[name]
+		if !exists || normalizeServer(existing.Server) == normalizeServer(incoming.Clusters[name].Server) {
+			continue
+		}
+		reason := fmt.Sprintf("Cluster %q already exists with server %s instead of %s", name, existing.Server, incoming.Clusters[name].Server)
+		newName, err := chooseImportName(reason, name, cfg.Clusters, incoming.Clusters)
+		if err != nil {
+			return nil, err
+		}
+		if newName == name {
+			continue
+		}
+		incoming.Clusters[newName] = incoming.Clusters[name]
+		delete(incoming.Clusters, name)
+		for _, ctx := range incoming.Contexts {
+			if ctx.Cluster == name {
+				ctx.Cluster = newName
+			}
+		}
+		changes = append(changes, fmt.Sprintf("Imported cluster %q as %q", name, newName))
+	}
+
+	for _, name := range sortedNames(incoming.AuthInfos) {
+		if _, exists := cfg.AuthInfos[name]; !exists {
+			continue
+		}
+		local, imported := userServers(cfg, name), userServers(incoming, name)
+		if len(local) == 0 || len(imported) == 0 || overlaps(local, imported) {
+			continue
+		}
+		reason := fmt.Sprintf("User %q already exists for a different cluster", name)
+		newName, err := chooseImportName(reason, name, cfg.AuthInfos, incoming.AuthInfos)
+		if err != nil {
+			return nil, err
+		}
+		if newName == name {
+			continue
+		}
+		incoming.AuthInfos[newName] = incoming.AuthInfos[name]
+		delete(incoming.AuthInfos, name)
+		for _, ctx := range incoming.Contexts {
+			if ctx.AuthInfo == name {
+				ctx.AuthInfo = newName
+			}
+		}
+		changes = append(changes, fmt.Sprintf("Imported user %q as %q", name, newName))
+	}
+
+	for _, name := range sortedNames(incoming.Contexts) {
+		existing, exists := cfg.Contexts[name]
+		if !exists {
+			continue
+		}
+		if local, ok := cfg.Clusters[existing.Cluster]; !ok || sameClusterServer(local, incoming.Clusters[incoming.Contexts[name].Cluster]) {
+			continue
+		}
+		reason := fmt.Sprintf("Context %q already exists for a different cluster", name)
+		newName, err := chooseImportName(reason, name, cfg.Contexts, incoming.Contexts)
+		if err != nil {
+			return nil, err
+		}
+		if newName == name {
+			continue
+		}
+		incoming.Contexts[newName] = incoming.Contexts[name]
+		delete(incoming.Contexts, name)
+		if incoming.CurrentContext == name {
+			incoming.CurrentContext = newName
+		}
+		changes = append(changes, fmt.Sprintf("Imported context %q as %q", name, newName))
+	}
+	return changes, nil
+}
+
+// chooseImportName proposes a free name for the incoming entry name and
+// lets the user change it.
+func chooseImportName[T any](reason, name string, existing, incoming map[string]T) (string, error) {
+	suffix := renameSuffixFlag
+	if suffix == "" {
+		suffix = defaultRenameSuffix
+	}
+	proposed := name + suffix
+	for i := 2; ; i++ {
+		_, taken := existing[proposed]
+		_, takenIncoming := incoming[proposed]
+		if !taken && !takenIncoming {
+			break
+		}
+		proposed = name + suffix + "-" + strconv.Itoa(i)
+	}
+	if !canPrompt() {
+		debugf("%s, importing it as %q", reason, proposed)
+		return proposed, nil
+	}
+
+	chosen := proposed
+	err := runForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title(reason + ". Import it as").
+				Description(fmt.Sprintf("Leave empty for %q, enter %q to overwrite the existing entry.", proposed, name)).
+				Placeholder(proposed).
+				Value(&chosen).
+				Validate(func(s string) error {
+					if _, taken := incoming[s]; taken && s != name {
+						return fmt.Errorf("%s is used by another incoming entry", s)
+					}
+					return nil
+				}),
+		),
+	)
+	if chosen == "" {
+		chosen = proposed
+	}
+	return chosen, err
+}
+
+// userServers returns the normalized servers of the clusters used by the
+// contexts of cfg that reference user.
+func userServers(cfg *api.Config, user string) map[string]bool {
+	servers := map[string]bool{}
+	for _, ctx := range cfg.Contexts {
+		if cluster, exists := cfg.Clusters[ctx.Cluster]; exists && ctx.AuthInfo == user {
+			servers[normalizeServer(cluster.Server)] = true
+		}
+	}
+	return servers
+}
+
+// overlaps reports whether a and b have a key in common.
+func overlaps(a, b map[string]bool) bool {
+	for key := range a {
+		if b[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// sameClusterServer reports whether both clusters point at the same server.
+// A missing cluster matches anything.
+func sameClusterServer(a, b *api.Cluster) bool {
+	return a == nil || b == nil || normalizeServer(a.Server) == normalizeServer(b.Server)
+}
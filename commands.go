@@ -0,0 +1,292 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// command is a subcommand selected by the first command-line argument.
+// Running the binary without a known subcommand starts the update flow.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{
+		name:    "delete-context",
+		usage:   "delete-context [--try] [name]",
+		summary: "Delete a context and its cluster and user if no longer referenced",
+		run:     runDeleteContext,
+	},
+	{
+		name:    "rename",
+		usage:   "rename [--cluster name] [--user name] [old new]",
+		summary: "Rename a context and optionally its cluster and user",
+		run:     runRename,
+	},
+	{
+		name:    "tag",
+		usage:   "tag [--remove] context [key=value ...]",
+		summary: "Show, set or remove tags like team, env or region of a context",
+		run:     runTag,
+	},
+	{
+		name:    "list",
+		usage:   "list [--json] [--tag key=value]",
+		summary: "Print all contexts with cluster, server, user and auth details",
+		run:     runList,
+	},
+	{
+		name:    "switch",
+		usage:   "switch [--tag key=value] [name]",
+		summary: "Set current-context, choosing from a filterable list",
+		run:     runSwitch,
+	},
+	{
+		name:    "set-namespace",
+		usage:   "set-namespace [context namespace]",
+		summary: "Set the default namespace of a context",
+		run:     runSetNamespace,
+	},
+	{
+		name:    "export",
+		usage:   "export [--output file] [name]",
+		summary: "Export a single context as a standalone kubeconfig",
+		run:     runExport,
+	},
+	{
+		name:    "split",
+		usage:   "split [--force] dir",
+		summary: "Write one standalone kubeconfig per context into dir",
+		run:     runSplit,
+	},
+	{
+		name:    "replace-server",
+		usage:   "replace-server [--try] [--yes] pattern replacement",
+		summary: "Rewrite the server URLs of all clusters matching a pattern",
+		run:     runReplaceServer,
+	},
+	{
+		name:    "flatten",
+		usage:   "flatten [--try] [--output file]",
+		summary: "Embed referenced certificate and key files as -data fields",
+		run:     runFlatten,
+	},
+	{
+		name:    "unflatten",
+		usage:   "unflatten [--try] [--dir dir]",
+		summary: "Extract embedded certificates and keys into files",
+		run:     runUnflatten,
+	},
+	{
+		name:    "inspect",
+		usage:   "inspect [context...]",
+		summary: "Show certificate subjects, issuers and expiry dates",
+		run:     runInspect,
+	},
+	{
+		name:    "check-ca",
+		usage:   "check-ca [context...]",
+		summary: "Check that live server certificates validate against the CA",
+		run:     runCheckCA,
+	},
+	{
+		name:    "check-all",
+		usage:   "check-all [--json] [--workers 10] [--timeout 5s] [--tag key=value]",
+		summary: "Probe the API servers of all contexts concurrently",
+		run:     runCheckAll,
+	},
+	{
+		name:    "fetch-ca",
+		usage:   "fetch-ca --cluster name [server]",
+		summary: "Fetch and trust the CA presented by a live server",
+		run:     runFetchCA,
+	},
+	{
+		name:    "refresh-token",
+		usage:   "refresh-token [context]",
+		summary: "Refresh an OIDC user's tokens via the device login flow",
+		run:     runRefreshToken,
+	},
+	{
+		name:    "rotate-token",
+		usage:   "rotate-token --service-account ns/name [context]",
+		summary: "Replace a user's token with a fresh ServiceAccount token",
+		run:     runRotateToken,
+	},
+	{
+		name:    "rotate-ca",
+		usage:   "rotate-ca --ca file (--server pattern | --fingerprint sha256) [--try] [--yes]",
+		summary: "Install a new CA in every cluster matching a server pattern or old CA",
+		run:     runRotateCA,
+	},
+	{
+		name:    "store-token",
+		usage:   "store-token [context...]",
+		summary: "Move tokens into the OS keychain",
+		run:     runStoreToken,
+	},
+	{
+		name:    "get-token",
+		usage:   "get-token user",
+		summary: "Exec credential plugin reading a token from the keychain",
+		run:     runGetToken,
+	},
+	{
+		name:    "encrypt",
+		usage:   "encrypt --age recipient | --pgp fp",
+		summary: "Encrypt the kubeconfig at rest with sops",
+		run:     runEncrypt,
+	},
+	{
+		name:    "view",
+		usage:   "view [context]",
+		summary: "Print the decrypted kubeconfig or one context",
+		run:     runView,
+	},
+	{
+		name:    "history",
+		usage:   "history [--context name] [-n count]",
+		summary: "Show changes recorded in the audit log",
+		run:     runHistory,
+	},
+	{
+		name:    "diff",
+		usage:   "diff [--show-secrets] [backup]",
+		summary: "Show what changed since the latest or a given backup",
+		run:     runDiff,
+	},
+	{
+		name:    "import",
+		usage:   "import <source> [flags]",
+		summary: "Import clusters from a cloud provider or tool",
+		run:     runImport,
+	},
+	{
+		name:    "run",
+		usage:   "run [--try] profile",
+		summary: "Repeat an update defined as profile in the settings file",
+		run:     runProfile,
+	},
+	{
+		name:    "batch",
+		usage:   "batch [--glob pattern] [--try] dir",
+		summary: "Merge every kubeconfig found in a directory tree",
+		run:     runBatch,
+	},
+	{
+		name:    "watch",
+		usage:   "watch [--auto] [--archive dir] dir",
+		summary: "Merge kubeconfig files dropped into a directory",
+		run:     runWatch,
+	},
+	{
+		name:    "daemon",
+		usage:   "daemon [--refresh-before 1h]",
+		summary: "Keep profiles fresh on a schedule and before credentials expire",
+		run:     runDaemon,
+	},
+	{
+		name:    "expiry",
+		usage:   "expiry [--json] [--warn-within 7d]",
+		summary: "List credential expiry of all contexts, soonest first",
+		run:     runExpiry,
+	},
+	{
+		name:    "lint",
+		usage:   "lint [--json] [--strict]",
+		summary: "Report broken references, weak settings and expiring credentials",
+		run:     runLint,
+	},
+	{
+		name:    "normalize",
+		usage:   "normalize [--try] [--check]",
+		summary: "Sort entries, drop duplicates and normalize formatting",
+		run:     runNormalize,
+	},
+	{
+		name:    "prune",
+		usage:   "prune [--try] [--tag key=value] [--remove-expired [--expired-for 30d]]",
+		summary: "Remove unused clusters and users, and tagged or expired contexts",
+		run:     runPrune,
+	},
+	{
+		name:    "serve",
+		usage:   "serve [--listen addr] [--token token]",
+		summary: "Serve a local HTTP API to list, export, merge and prune",
+		run:     runServe,
+	},
+	{
+		name:    "tui",
+		usage:   "tui",
+		summary: "Browse and edit contexts, clusters and users full-screen",
+		run:     runTUI,
+	},
+	{
+		name:    "version",
+		usage:   "version",
+		summary: "Print version and build information",
+		run:     runVersion,
+	},
+	{
+		name:    "self-update",
+		usage:   "self-update [--check] [--yes]",
+		summary: "Replace this binary with the latest GitHub release",
+		run:     runSelfUpdate,
+	},
+}
+
+// findCommand returns the subcommand with the given name, or nil.
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// usage prints the help text for the update flow and all subcommands.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags]\n\n", programName())
+	fmt.Fprintln(out, "Update a context interactively from a pasted kubeconfig.")
+	fmt.Fprintln(out)
+	flag.PrintDefaults()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-32s %s\n", cmd.usage, cmd.summary)
+	}
+}
+
+// newFlagSet returns a flag set for a subcommand with the common --config flag.
+func newFlagSet(cmd string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	configPath := fs.String("config", userSettings.Kubeconfig, "Path to kubeconfig file")
+	addDebugFlags(fs)
+	addUIFlags(fs)
+	addOutputFormatFlag(fs)
+	return fs, configPath
+}
+
+// sharedFlagArgs returns the flags of newFlagSet and --strategy with their
+// current values, to pass on to a command run from another one. Registering
+// the flags again on the nested flag set resets them to their defaults.
+func sharedFlagArgs() []string {
+	args := []string{
+		fmt.Sprintf("--debug=%t", debugEnabled),
+		fmt.Sprintf("--quiet=%t", quietEnabled),
+		fmt.Sprintf("--plain=%t", plainFlag),
+		"--theme=" + themeFlag,
+		"--accent-color=" + accentColorFlag,
+		"--output-format=" + outputFormatFlag,
+	}
+	if strategyFlag != "" {
+		args = append(args, "--strategy="+string(strategyFlag))
+	}
+	return args
+}
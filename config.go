@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const defaultConfigPath = "~/.kube/config"
+
+var outputFormatFlag string
+
+// addOutputFormatFlag registers the --output-format flag on fs.
+func addOutputFormatFlag(fs *flag.FlagSet) {
+	fs.StringVar(&outputFormatFlag, "output-format", "", "Write kubeconfigs as yaml or json (default: keep the current format)")
+}
+
+// outputFormat returns the format to write a kubeconfig read from data in:
+// the one given with --output-format, or else the format of data.
+func outputFormat(data []byte) string {
+	if outputFormatFlag != "" {
+		return outputFormatFlag
+	}
+	return kubeconfig.DetectFormat(data)
+}
+
+// expandPath expands a path like a shell would: $VAR and ${VAR} references
+// to environment variables, a leading ~ to the user's home directory and
+// ~user to the home directory of user. On Windows, where the home directory
+// is %USERPROFILE%, it also accepts ~\ and expands %VAR% references such as
+// %USERPROFILE%\.kube\config.
+func expandPath(path string) (string, error) {
+	separators := "/"
+	if runtime.GOOS == "windows" {
+		path = expandWindowsEnv(path)
+		separators += `\`
+	}
+	path = os.ExpandEnv(path)
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, separators); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	if name != "" {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("expanding %s: %w", path, err)
+		}
+		return filepath.Join(u.HomeDir, rest), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// windowsEnvVar matches %VAR% references in Windows paths.
+var windowsEnvVar = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_()]*)%`)
+
+// expandWindowsEnv replaces %VAR% references in path by the values of the
+// environment variables, leaving unset variables as they are like cmd.exe.
+func expandWindowsEnv(path string) string {
+	return windowsEnvVar.ReplaceAllStringFunc(path, func(ref string) string {
+		if value, ok := os.LookupEnv(ref[1 : len(ref)-1]); ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// loadConfig reads and parses the kubeconfig at path, decrypting it first if
+// it is encrypted with sops. The raw file content is returned alongside the
+// parsed config so it can be backed up on save.
+func loadConfig(path string) ([]byte, *api.Config, error) {
+	data, cfg, err := kubeconfig.Load(path)
+	if err == nil {
+		loadedConfigs[path] = cfg.DeepCopy()
+	}
+	return data, cfg, err
+}
+
+// loadedConfigs holds the kubeconfigs as last loaded or saved, by path, to
+// tell which contexts a save updates.
+var loadedConfigs = map[string]*api.Config{}
+
+// stampUpdated records the time and source of the running operation on the
+// contexts of cfg whose credentials changed since path was loaded.
+func stampUpdated(path string, origData []byte, cfg *api.Config) {
+	before, loaded := loadedConfigs[path]
+	if !loaded {
+		if len(bytes.TrimSpace(origData)) > 0 {
+			debugf("%s was not loaded before, not stamping updated contexts", path)
+			return
+		}
+		before = api.NewConfig()
+	}
+	stamp := kubeconfig.LastUpdated{Time: time.Now().UTC().Truncate(time.Second), Source: audit.source}
+	stamped, err := kubeconfig.StampUpdated(before, cfg, stamp)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: recording last update: %v\n", err)
+		return
+	}
+	if len(stamped) > 0 {
+		debugf("stamped contexts %s as updated", quoteList(stamped))
+	}
+}
+
+// saveConfig writes origData to a timestamped backup and then replaces path
+// with the serialized cfg. A kubeconfig that was encrypted with sops is
+// encrypted again with the same keys. Contexts whose credentials changed are
+// stamped with the last-updated extension, and the applied changes are
+// recorded in the audit log.
+func saveConfig(path string, origData []byte, cfg *api.Config, changes []string) error {
+	stampUpdated(path, origData, cfg)
+	outData, err := kubeconfig.Encode(path, origData, cfg, outputFormatFlag)
+	if err != nil {
+		return err
+	}
+	if err := writeConfig(path, origData, outData, changes); err != nil {
+		return err
+	}
+	loadedConfigs[path] = cfg.DeepCopy()
+	return nil
+}
+
+// writeConfig backs up origData, replaces path with outData, records
+// changes in the audit log and sends them to the configured webhook and
+// the --notify webhooks.
+func writeConfig(path string, origData, outData []byte, changes []string) error {
+	dir, err := backupDir()
+	if err != nil {
+		return err
+	}
+	backupPath, err := kubeconfig.Save(path, origData, outData, dir)
+	if backupPath != "" {
+		infof("Backup saved to %s\n", backupPath)
+	}
+	if err != nil {
+		return err
+	}
+	if userSettings.BackupRetention > 0 {
+		if err := kubeconfig.PruneBackups(path, dir, userSettings.BackupRetention); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: removing old backups: %v\n", err)
+		}
+	}
+	infof("Successfully updated %s\n", path)
+	appendAuditLog(path, changes)
+	notifyWebhook(path, changes)
+	sendNotifications(path, changes)
+	return nil
+}
+
+// backupDir returns the configured backup directory, or an empty string to
+// keep backups next to the kubeconfig.
+func backupDir() (string, error) {
+	if userSettings.BackupDir == "" {
+		return "", nil
+	}
+	return expandPath(userSettings.BackupDir)
+}
+
+// printChanges prints the summary of changes collected during a run, unless
+// quiet mode is enabled.
+func printChanges(changes []string) {
+	if quietEnabled {
+		return
+	}
+	fmt.Println("Summary of changes:")
+	if len(changes) == 0 {
+		fmt.Println("No changes made.")
+		return
+	}
+	for _, change := range changes {
+		fmt.Println("- " + change)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// mergeStrategies are the values accepted by --strategy. Without a strategy
+// the sync history decides, see resolveConflict.
+var mergeStrategies = []string{"theirs", "ours", "prompt"}
+
+// mergeStrategy is a flag.Value accepting one of mergeStrategies.
+type mergeStrategy string
+
+var strategyFlag mergeStrategy
+
+func (s *mergeStrategy) String() string { return string(*s) }
+
+func (s *mergeStrategy) Set(value string) error {
+	for _, strategy := range mergeStrategies {
+		if value == strategy {
+			*s = mergeStrategy(value)
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s", strings.Join(mergeStrategies, ", "))
+}
+
+// addStrategyFlag registers the --strategy flag on fs, defaulting to the
+// merge-strategy setting.
+func addStrategyFlag(fs *flag.FlagSet) {
+	strategyFlag = mergeStrategy(userSettings.MergeStrategy)
+	fs.Var(&strategyFlag, "strategy", "Merge strategy: theirs (take incoming values), ours (only fill missing fields) "+
+		"or prompt (ask per differing field); by default only conflicts since the last sync are asked")
+}
+
+// resolveConflict is the merge resolver of all commands. It follows
+// --strategy if given. Otherwise it keeps fields that were only changed
+// locally since the last sync and asks which value to keep when both sides
+// changed. Without a terminal, conflicts are resolved in favor of the
+// incoming value with a warning.
+func resolveConflict(c kubeconfig.FieldChange) (bool, error) {
+	switch strategyFlag {
+	case "theirs":
+		return true, nil
+	case "ours":
+		return c.LocalEmpty, nil
+	case "prompt":
+		if c.LocalEmpty {
+			return true, nil
+		}
+		return promptConflict(fmt.Sprintf("%s differs", c), c)
+	}
+
+	switch {
+	case c.Synced && c.LocalChanged && !c.IncomingChanged:
+		debugf("keeping local %s, the incoming value did not change since the last sync", c)
+		return false, nil
+	case !c.Conflict():
+		return true, nil
+	case !canPrompt():
+		fmt.Fprintf(os.Stderr, "Warning: %s changed locally and upstream since the last sync, taking the incoming value %s over %s\n",
+			c, c.Incoming, c.Local)
+		return true, nil
+	}
+	return promptConflict(fmt.Sprintf("Conflict: %s changed locally and upstream since the last sync", c), c)
+}
+
+// promptConflict asks whether to keep the local or take the incoming value
+// of the field described by c.
+func promptConflict(title string, c kubeconfig.FieldChange) (bool, error) {
+	var take bool
+	err := runForm(
+		huh.NewGroup(
+			huh.NewSelect[bool]().
+				Title(title).
+				Options(
+					huh.NewOption("Keep local value "+c.Local, false),
+					huh.NewOption("Take incoming value "+c.Incoming, true),
+				).
+				Value(&take),
+		),
+	)
+	return take, err
+}
+
+// selectFields asks which of the fields that differ between the named
+// cluster and user in cfg and the incoming ones should be taken, and returns
+// a resolver taking exactly those. cfg is not modified.
+func selectFields(cfg *api.Config, clusterName string, cluster *api.Cluster, userName string, user *api.AuthInfo) (kubeconfig.Resolver, error) {
+	var differing []kubeconfig.FieldChange
+	collect := func(c kubeconfig.FieldChange) (bool, error) {
+		differing = append(differing, c)
+		return false, nil
+	}
+	scratch := cfg.DeepCopy()
+	if _, err := kubeconfig.MergeCluster(scratch, clusterName, cluster.DeepCopy(), true, collect); err != nil {
+		return nil, err
+	}
+	if _, err := kubeconfig.MergeUser(scratch, userName, user.DeepCopy(), collect); err != nil {
+		return nil, err
+	}
+	if len(differing) == 0 {
+		return resolveConflict, nil
+	}
+
+	options := make([]huh.Option[string], len(differing))
+	var selected []string
+	for i, c := range differing {
+		options[i] = huh.NewOption(fmt.Sprintf("%s: %s → %s", c, c.Local, c.Incoming), c.String()).Selected(true)
+		selected = append(selected, c.String())
+	}
+	err := runForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Select the fields to take from the new kubeconfig").
+				Options(options...).
+				Value(&selected),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("selecting fields: %w", err)
+	}
+	take := map[string]bool{}
+	for _, field := range selected {
+		take[field] = true
+	}
+	return func(c kubeconfig.FieldChange) (bool, error) {
+		return take[c.String()], nil
+	}, nil
+}
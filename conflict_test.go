@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+)
+
+func TestResolveConflict(t *testing.T) {
+	var (
+		unsynced      = kubeconfig.FieldChange{}
+		unsyncedEmpty = kubeconfig.FieldChange{LocalEmpty: true}
+		upstream      = kubeconfig.FieldChange{Synced: true, IncomingChanged: true}
+		local         = kubeconfig.FieldChange{Synced: true, LocalChanged: true}
+		conflict      = kubeconfig.FieldChange{Synced: true, LocalChanged: true, IncomingChanged: true}
+	)
+	tests := []struct {
+		strategy mergeStrategy
+		change   kubeconfig.FieldChange
+		want     bool
+	}{
+		{"", unsynced, true},
+		{"", upstream, true},
+		{"", local, false},
+		// Without a terminal conflicts go to the incoming value
+		{"", conflict, true},
+		{"theirs", local, true},
+		{"theirs", conflict, true},
+		{"ours", unsynced, false},
+		{"ours", upstream, false},
+		{"ours", conflict, false},
+		{"ours", unsyncedEmpty, true},
+		{"prompt", unsyncedEmpty, true},
+	}
+	defer func(s mergeStrategy) { strategyFlag = s }(strategyFlag)
+	for _, tt := range tests {
+		strategyFlag = tt.strategy
+		got, err := resolveConflict(tt.change)
+		if err != nil {
+			t.Fatalf("strategy %q, %+v: %v", tt.strategy, tt.change, err)
+		}
+		if got != tt.want {
+			t.Errorf("strategy %q, %+v: take = %t, want %t", tt.strategy, tt.change, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+)
+
+func runDaemon(args []string) error {
+	fs, configPathFlag := newFlagSet("daemon")
+	addStrategyFlag(fs)
+	addNotifyFlag(fs)
+	refreshBeforeFlag := fs.Duration("refresh-before", time.Hour, "Refresh a profile's context when its credentials expire within this time")
+	checkFlag := fs.Duration("check", time.Minute, "How often to check schedules and credential expiry")
+	fs.Parse(args)
+
+	if len(userSettings.Profiles) == 0 {
+		return fmt.Errorf("no profiles defined in the settings file")
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, name := range sortedNames(userSettings.Profiles) {
+		p := userSettings.Profiles[name]
+		if p.Every == 0 && p.Context == "" {
+			log.Printf("Profile %s has neither a schedule nor a context, ignoring it", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no profile has a schedule (every) or a context to keep fresh")
+	}
+	log.Printf("Refreshing profiles %s into %s", quoteList(names), configPath)
+
+	lastRun := map[string]time.Time{}
+	for {
+		for _, name := range names {
+			reason := refreshReason(configPath, userSettings.Profiles[name], lastRun[name], *refreshBeforeFlag)
+			if reason == "" {
+				continue
+			}
+			log.Printf("Refreshing profile %s: %s", name, reason)
+			lastRun[name] = time.Now()
+			if err := refreshProfile(configPath, name); err != nil {
+				log.Printf("Refreshing profile %s failed: %v", name, err)
+			}
+		}
+		time.Sleep(*checkFlag)
+	}
+}
+
+// refreshProfile runs the named profile against configPath with the flags
+// the daemon was started with.
+func refreshProfile(configPath, name string) error {
+	return runProfile(append(sharedFlagArgs(), "--config", configPath, name))
+}
+
+// refreshReason returns why profile p is due for a refresh, or an empty
+// string if it is not: its schedule elapsed since lastRun, or the
+// credentials of its context expire within refreshBefore.
+func refreshReason(configPath string, p profile, lastRun time.Time, refreshBefore time.Duration) string {
+	if lastRun.IsZero() && p.Every > 0 {
+		return "first run"
+	}
+	if p.Every > 0 && time.Since(lastRun) >= p.Every {
+		return fmt.Sprintf("scheduled every %s", p.Every)
+	}
+	if p.Context == "" {
+		return ""
+	}
+	_, cfg, err := loadConfig(configPath)
+	if err != nil {
+		log.Printf("Checking credentials: %v", err)
+		return ""
+	}
+	ctx, exists := cfg.Contexts[p.Context]
+	if !exists {
+		return fmt.Sprintf("context %s does not exist yet", p.Context)
+	}
+	user, exists := cfg.AuthInfos[ctx.AuthInfo]
+	if !exists {
+		return fmt.Sprintf("user %s does not exist yet", ctx.AuthInfo)
+	}
+	expiry, ok := credentialExpiry(user)
+	if !ok || time.Until(expiry) > refreshBefore {
+		return ""
+	}
+	// Don't hammer a source that keeps returning expiring credentials
+	if time.Since(lastRun) < refreshBefore/4 {
+		return ""
+	}
+	return fmt.Sprintf("credentials of context %s expire soon (%s)", p.Context, kubeconfig.DescribeExpiry(expiry))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestSharedFlagsReachNestedImport(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configPath, []byte("apiVersion: v1\nkind: Config\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fake import source recording the flags in effect when it runs
+	var gotStrategy mergeStrategy
+	var gotQuiet, gotDebug bool
+	errStop := errors.New("stop")
+	defer func(sources []importSource) { importSources = sources }(importSources)
+	importSources = append(importSources, importSource{
+		name: "test",
+		setup: func(fs *flag.FlagSet) func() (*api.Config, error) {
+			return func() (*api.Config, error) {
+				gotStrategy, gotQuiet, gotDebug = strategyFlag, quietEnabled, debugEnabled
+				return nil, errStop
+			}
+		},
+	})
+	defer func(profiles map[string]profile) { userSettings.Profiles = profiles }(userSettings.Profiles)
+	userSettings.Profiles = map[string]profile{"nested": {Import: "test"}}
+	defer func() { strategyFlag, quietEnabled, debugEnabled = "", false, false }()
+
+	// Parse the daemon's flags as runDaemon does, then refresh the profile
+	fs, _ := newFlagSet("daemon")
+	addStrategyFlag(fs)
+	if err := fs.Parse([]string{"--strategy", "ours", "-q", "-v"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := refreshProfile(configPath, "nested"); !errors.Is(err, errStop) {
+		t.Fatalf("refreshProfile() = %v, want the fake source's error", err)
+	}
+	if gotStrategy != "ours" || !gotQuiet || !gotDebug {
+		t.Errorf("import ran with strategy %q, quiet %t, debug %t; want ours, true, true", gotStrategy, gotQuiet, gotDebug)
+	}
+}
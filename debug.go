@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+)
+
+// debugEnabled is set by -v/--debug.
+var debugEnabled bool
+
+// quietEnabled is set by -q/--quiet.
+var quietEnabled bool
+
+func init() {
+	kubeconfig.Debugf = debugf
+}
+
+// addDebugFlags registers -v, --debug, -q and --quiet on fs.
+func addDebugFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&debugEnabled, "v", false, "Log merge decisions and file access to stderr")
+	fs.BoolVar(&debugEnabled, "debug", false, "Same as -v")
+	fs.BoolVar(&quietEnabled, "q", false, "Print only errors, no summary or progress messages")
+	fs.BoolVar(&quietEnabled, "quiet", false, "Same as -q")
+}
+
+// debugf logs a message to stderr if debug logging is enabled.
+func debugf(format string, args ...interface{}) {
+	if debugEnabled {
+		fmt.Fprintf(os.Stderr, "debug: "+format+"\n", args...)
+	}
+}
+
+// infof prints an informational message to stdout unless quiet mode is
+// enabled.
+func infof(format string, args ...interface{}) {
+	if !quietEnabled {
+		fmt.Printf(format, args...)
+	}
+}
+
+// failf prints a failure that is part of a command's report to stdout, or
+// to stderr in quiet mode, where only errors are printed.
+func failf(format string, args ...interface{}) {
+	if quietEnabled {
+		fmt.Fprintf(os.Stderr, format, args...)
+	} else {
+		fmt.Printf(format, args...)
+	}
+}
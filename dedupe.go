@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// normalizeServer returns server in a canonical form for comparison: scheme
+// and host lowercased, the default https port and trailing slashes dropped.
+func normalizeServer(server string) string {
+	u, err := url.Parse(strings.TrimSpace(server))
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(strings.TrimSpace(server), "/")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	return scheme + "://" + host + strings.TrimSuffix(u.Path, "/")
+}
+
+// clustersWithServer returns the sorted names of the clusters in cfg whose
+// server matches server.
+func clustersWithServer(cfg *api.Config, server string) []string {
+	var names []string
+	for name, cluster := range cfg.Clusters {
+		if server != "" && normalizeServer(cluster.Server) == normalizeServer(server) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// chooseCanonicalCluster is called before adding the cluster name with the
+// given server to cfg. If clusters with the same server already exist, it
+// offers to update one of them instead and returns the name to merge into.
+// Contexts using further duplicates can be pointed at the chosen cluster,
+// removing the duplicates; the changes made to cfg are returned.
+func chooseCanonicalCluster(cfg *api.Config, name, server string) (string, []string, error) {
+	if _, exists := cfg.Clusters[name]; exists {
+		return name, nil, nil
+	}
+	matches := clustersWithServer(cfg, server)
+	if len(matches) == 0 {
+		return name, nil, nil
+	}
+	if !canPrompt() {
+		debugf("cluster %q has the same server as %s, adding it anyway without a terminal to ask", name, quoteList(matches))
+		return name, nil, nil
+	}
+
+	options := []huh.Option[string]{}
+	for _, match := range matches {
+		options = append(options, huh.NewOption(fmt.Sprintf("Update existing cluster %q", match), match))
+	}
+	options = append(options, huh.NewOption(fmt.Sprintf("Add %q as a new cluster", name), name))
+	canonical := matches[0]
+	err := runForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("Cluster %q has the same server %s as %s", name, server, quoteList(matches))).
+				Options(options...).
+				Value(&canonical),
+		),
+	)
+	if err != nil || canonical == name || len(matches) == 1 {
+		return canonical, nil, err
+	}
+
+	var duplicates []string
+	for _, match := range matches {
+		if match != canonical {
+			duplicates = append(duplicates, match)
+		}
+	}
+	var repoint bool
+	err = runForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Point contexts using %s at %q and remove the duplicates?", quoteList(duplicates), canonical)).
+				Value(&repoint),
+		),
+	)
+	if err != nil || !repoint {
+		return canonical, nil, err
+	}
+	var changes []string
+	for _, duplicate := range duplicates {
+		for _, ctxName := range contextsUsingCluster(cfg, duplicate) {
+			cfg.Contexts[ctxName].Cluster = canonical
+			changes = append(changes, fmt.Sprintf("Pointed context %q at cluster %q instead of %q", ctxName, canonical, duplicate))
+		}
+		delete(cfg.Clusters, duplicate)
+		changes = append(changes, fmt.Sprintf("Removed duplicate cluster %q", duplicate))
+	}
+	return canonical, changes, nil
+}
+
+// dedupeClusters runs chooseCanonicalCluster for every cluster of incoming
+// and renames the clusters of incoming to the chosen names, so they update
+// the existing entries when merged.
+func dedupeClusters(cfg, incoming *api.Config) ([]string, error) {
+	var changes []string
+	for _, name := range sortedNames(incoming.Clusters) {
+		canonical, dedupeChanges, err := chooseCanonicalCluster(cfg, name, incoming.Clusters[name].Server)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, dedupeChanges...)
+		if canonical == name {
+			continue
+		}
+		if _, exists := incoming.Clusters[canonical]; exists {
+			return nil, fmt.Errorf("cannot merge cluster %q into %q, the incoming kubeconfig has both", name, canonical)
+		}
+		incoming.Clusters[canonical] = incoming.Clusters[name]
+		delete(incoming.Clusters, name)
+		for _, ctx := range incoming.Contexts {
+			if ctx.Cluster == name {
+				ctx.Cluster = canonical
+			}
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runDeleteContext(args []string) error {
+	fs, configPathFlag := newFlagSet("delete-context")
+	var try bool
+	fs.BoolVar(&try, "try", false, "Try mode: do not update file, just print what would be removed")
+	fs.BoolVar(&try, "dry-run", false, "Alias for --try")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	switch fs.NArg() {
+	case 0:
+		name, err = selectContext(cfg, "Select a context to delete")
+		if err != nil {
+			return fmt.Errorf("selecting context: %w", err)
+		}
+		var confirmed bool
+		err = runForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Delete context %s?", name)).
+					Description("Its cluster and user are removed too unless another context uses them.").
+					Value(&confirmed),
+			),
+		)
+		if err != nil {
+			return fmt.Errorf("getting delete confirmation: %w", err)
+		}
+		if !confirmed {
+			infof("Aborted.\n")
+			return nil
+		}
+	case 1:
+		name = fs.Arg(0)
+	default:
+		return fmt.Errorf("expected at most one context name, got %d", fs.NArg())
+	}
+
+	changes, err := deleteContext(cfg, name)
+	if err != nil {
+		return err
+	}
+	printChanges(changes)
+	if try {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// deleteContext removes the named context from cfg along with its cluster
+// and user, unless those are still referenced by another context.
+func deleteContext(cfg *api.Config, name string) ([]string, error) {
+	ctx, exists := cfg.Contexts[name]
+	if !exists {
+		return nil, fmt.Errorf("context %s not found", name)
+	}
+	delete(cfg.Contexts, name)
+	changes := []string{fmt.Sprintf("Removed context %q", name)}
+
+	if cfg.CurrentContext == name {
+		cfg.CurrentContext = ""
+		changes = append(changes, fmt.Sprintf("Unset current-context (was %q)", name))
+	}
+
+	if users := contextsUsingCluster(cfg, ctx.Cluster); len(users) > 0 {
+		changes = append(changes, fmt.Sprintf("Kept cluster %q (still used by %s)", ctx.Cluster, quoteList(users)))
+	} else if cluster, exists := cfg.Clusters[ctx.Cluster]; exists {
+		delete(cfg.Clusters, ctx.Cluster)
+		changes = append(changes, fmt.Sprintf("Removed cluster %q with server %s", ctx.Cluster, cluster.Server))
+	}
+
+	if users := contextsUsingUser(cfg, ctx.AuthInfo); len(users) > 0 {
+		changes = append(changes, fmt.Sprintf("Kept user %q (still used by %s)", ctx.AuthInfo, quoteList(users)))
+	} else if _, exists := cfg.AuthInfos[ctx.AuthInfo]; exists {
+		delete(cfg.AuthInfos, ctx.AuthInfo)
+		changes = append(changes, fmt.Sprintf("Removed user %q", ctx.AuthInfo))
+	}
+	return changes, nil
+}
+
+// contextsUsingCluster returns the sorted names of contexts referencing cluster.
+func contextsUsingCluster(cfg *api.Config, cluster string) []string {
+	var names []string
+	for name, ctx := range cfg.Contexts {
+		if ctx.Cluster == cluster {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// contextsUsingUser returns the sorted names of contexts referencing user.
+func contextsUsingUser(cfg *api.Config, user string) []string {
+	var names []string
+	for name, ctx := range cfg.Contexts {
+		if ctx.AuthInfo == user {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectContext prompts for one of the contexts in cfg.
+func selectContext(cfg *api.Config, title string) (string, error) {
+	if len(cfg.Contexts) == 0 {
+		return "", fmt.Errorf("kubeconfig has no contexts")
+	}
+	var selected string
+	err := runForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(filterTitle(title)).
+				Options(contextOptions(cfg)...).
+				Filtering(true).
+				Height(15).
+				Value(&selected),
+		),
+	)
+	return selected, err
+}
+
+// contextOptions returns the contexts of cfg as select options, sorted by
+// name with the current context pinned at the top. Labels include the
+// server and tags, so filtering matches on them too.
+func contextOptions(cfg *api.Config) []huh.Option[string] {
+	var names []string
+	for name := range cfg.Contexts {
+		if name != cfg.CurrentContext {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, exists := cfg.Contexts[cfg.CurrentContext]; exists {
+		names = append([]string{cfg.CurrentContext}, names...)
+	}
+
+	var options []huh.Option[string]
+	for _, name := range names {
+		label := name
+		if cluster, exists := cfg.Clusters[cfg.Contexts[name].Cluster]; exists {
+			label += "  " + cluster.Server
+		}
+		if tags, _ := kubeconfig.GetTags(cfg.Contexts[name]); len(tags) > 0 {
+			label += " [" + kubeconfig.FormatTags(tags) + "]"
+		}
+		if name == cfg.CurrentContext {
+			label += " (current)"
+		}
+		options = append(options, huh.NewOption(label, name))
+	}
+	return options
+}
+
+// quoteList formats names as a comma separated list of quoted strings.
+func quoteList(names []string) string {
+	var s string
+	for i, name := range names {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%q", name)
+	}
+	return s
+}
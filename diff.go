@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+func runDiff(args []string) error {
+	fs, configPathFlag := newFlagSet("diff")
+	showSecrets := fs.Bool("show-secrets", false, "Print tokens and keys in full")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("expected at most one backup, got %d", fs.NArg())
+	}
+	dir, err := backupDir()
+	if err != nil {
+		return err
+	}
+	backups, err := kubeconfig.ListBackups(configPath, dir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("listing backups: %w", err)
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("no backups of %s found", configPath)
+	}
+
+	backup := backups[len(backups)-1]
+	if fs.NArg() == 1 {
+		if backup, err = findBackup(backups, fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	old, err := diffableConfig(backup, *showSecrets)
+	if err != nil {
+		return err
+	}
+	current, err := diffableConfig(configPath, *showSecrets)
+	if err != nil {
+		return err
+	}
+	fmt.Print(unifiedDiff(backup, configPath, old, current))
+	return nil
+}
+
+// findBackup returns the backup matching name, which may be its path, its
+// file name or just its timestamp.
+func findBackup(backups []string, name string) (string, error) {
+	for _, backup := range backups {
+		if backup == name || filepath.Base(backup) == name || strings.HasSuffix(backup, ".backup."+name) {
+			return backup, nil
+		}
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	return "", fmt.Errorf("backup %s not found", name)
+}
+
+// diffableConfig loads the kubeconfig at path and serializes it again, so
+// formatting and sops encryption do not show up in the diff. Secrets are
+// redacted unless showSecrets is set.
+func diffableConfig(path string, showSecrets bool) ([]string, error) {
+	_, cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := configLines(cfg, showSecrets)
+	if err != nil {
+		return nil, fmt.Errorf("serializing %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+// configLines serializes cfg into lines for unifiedDiff, in the format given
+// with --output-format or else as YAML, with secrets redacted unless
+// showSecrets is set.
+func configLines(cfg *api.Config, showSecrets bool) ([]string, error) {
+	data, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !showSecrets {
+		if data, err = redactSecrets(data); err != nil {
+			return nil, err
+		}
+	}
+	if data, err = kubeconfig.ConvertFormat(data, outputFormatFlag); err != nil {
+		return nil, err
+	}
+	return strings.SplitAfter(strings.TrimSuffix(string(data), "\n")+"\n", "\n"), nil
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed or '+' added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the differences between the lines a and b in unified
+// diff format, or an empty string if they are equal.
+func unifiedDiff(aName, bName string, a, b []string) string {
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk, merging changes
+		// whose context overlaps.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops) && i <= last+2*diffContext; i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			}
+		}
+		from := max(first-diffContext, start)
+		to := min(last+diffContext+1, len(ops))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+		}
+		aStart, bStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+		var aLen, bLen int
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// diffLines returns an edit script turning a into b, based on the longest
+// common subsequence of the lines between their common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of am[i:]
+	// and bm[j:].
+	lcs := make([][]int, len(am)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bm)+1)
+	}
+	for i := len(am) - 1; i >= 0; i-- {
+		for j := len(bm) - 1; j >= 0; j-- {
+			if am[i] == bm[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	i, j := 0, 0
+	for i < len(am) || j < len(bm) {
+		switch {
+		case i < len(am) && j < len(bm) && am[i] == bm[j]:
+			ops = append(ops, diffOp{' ', am[i]})
+			i++
+			j++
+		case j == len(bm) || (i < len(am) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', am[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', bm[j]})
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
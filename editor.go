@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const editorTemplate = `# Paste the new kubeconfig below, then save and quit the editor.
+# Lines starting with '#' are ignored. An empty file aborts the update.
+`
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, split
+// into program and arguments, falling back to vi (notepad on Windows).
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editKubeconfig opens the user's editor on a temporary file and returns its
+// content once the editor exits. The file is only readable by the user and
+// removed afterwards, since it usually holds credentials.
+func editKubeconfig() (string, error) {
+	f, err := os.CreateTemp("", "kubeconfig-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(editorTemplate)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	editor := editorCommand()
+	debugf("running editor %q on %s", editor, f.Name())
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running editor %s: %w", editor[0], err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("reading temporary file: %w", err)
+	}
+	var content []byte
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("#")) {
+			content = append(content, line...)
+		}
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return "", fmt.Errorf("editor returned an empty kubeconfig, aborting")
+	}
+	return string(content), nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// expiredCredentials returns a warning for every credential of user that is
+// already expired: client certificates past their NotAfter date and JWT
+// tokens whose exp claim lies in the past.
+func expiredCredentials(user *api.AuthInfo) []string {
+	var warnings []string
+	now := time.Now()
+	for _, cert := range kubeconfig.ParseCertificates(user.ClientCertificateData) {
+		if now.After(cert.NotAfter) {
+			warnings = append(warnings, fmt.Sprintf("client certificate %q expired %s ago (%s)",
+				cert.Subject.String(), kubeconfig.FormatDays(now.Sub(cert.NotAfter)), cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	if claims, err := kubeconfig.DecodeJWTClaims(user.Token); err == nil {
+		if exp, ok := claims.Expiry(); ok && now.After(exp) {
+			warnings = append(warnings, fmt.Sprintf("token expired %s ago (%s)",
+				kubeconfig.FormatDays(now.Sub(exp)), exp.Format(time.RFC3339)))
+		}
+	}
+	return warnings
+}
+
+// credentialExpiry returns when the first credential of user expires, taking
+// client certificates and JWT tokens into account. It reports false if no
+// credential has a known expiry.
+func credentialExpiry(user *api.AuthInfo) (time.Time, bool) {
+	var earliest time.Time
+	for _, cert := range kubeconfig.ParseCertificates(user.ClientCertificateData) {
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+	if claims, err := kubeconfig.DecodeJWTClaims(user.Token); err == nil {
+		if exp, ok := claims.Expiry(); ok && (earliest.IsZero() || exp.Before(earliest)) {
+			earliest = exp
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// contextExpiry is a row of the expiry command.
+type contextExpiry struct {
+	Context  string     `json:"context"`
+	User     string     `json:"user"`
+	AuthType string     `json:"authType"`
+	Expires  *time.Time `json:"expires,omitempty"`
+}
+
+func runExpiry(args []string) error {
+	fs, configPathFlag := newFlagSet("expiry")
+	jsonFlag := fs.Bool("json", false, "Print the expiry of contexts as JSON")
+	var warnWithin dayDuration
+	fs.Var(&warnWithin, "warn-within", "Fail if a credential expires within this time, e.g. 7d or 12h")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	_, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	rows := expiryRows(cfg)
+	if *jsonFlag {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CONTEXT\tUSER\tAUTH\tEXPIRES\tIN")
+		for _, row := range rows {
+			expires, in := "n/a", "n/a"
+			if row.Expires != nil {
+				expires = row.Expires.Format(time.RFC3339)
+				in = kubeconfig.DescribeExpiry(*row.Expires)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", row.Context, row.User, row.AuthType, expires, in)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if warnWithin > 0 {
+		var expiring []string
+		for _, row := range rows {
+			if row.Expires != nil && time.Until(*row.Expires) < time.Duration(warnWithin) {
+				expiring = append(expiring, row.Context)
+			}
+		}
+		if len(expiring) > 0 {
+			return fmt.Errorf("credentials of %d contexts expire within %s: %s", len(expiring), &warnWithin, quoteList(expiring))
+		}
+	}
+	return nil
+}
+
+// expiryRows returns the credential expiry of every context of cfg, soonest
+// first. Contexts without a known expiry, e.g. exec plugins that fetch
+// fresh credentials, come last.
+func expiryRows(cfg *api.Config) []contextExpiry {
+	var rows []contextExpiry
+	for _, name := range sortedNames(cfg.Contexts) {
+		ctx := cfg.Contexts[name]
+		row := contextExpiry{Context: name, User: ctx.AuthInfo, AuthType: "<missing>"}
+		if user, exists := cfg.AuthInfos[ctx.AuthInfo]; exists {
+			row.AuthType = authType(user)
+			if expiry, ok := credentialExpiry(user); ok {
+				row.Expires = &expiry
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].Expires, rows[j].Expires
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+	return rows
+}
+
+// dayDuration is a flag.Value for durations that also accepts whole days,
+// e.g. 7d, next to the units of time.ParseDuration.
+type dayDuration time.Duration
+
+func (d *dayDuration) String() string {
+	if *d > 0 && time.Duration(*d)%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", time.Duration(*d)/(24*time.Hour))
+	}
+	return time.Duration(*d).String()
+}
+
+func (d *dayDuration) Set(value string) error {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid number of days %q", value)
+		}
+		*d = dayDuration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = dayDuration(parsed)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runExport(args []string) error {
+	fs, configPathFlag := newFlagSet("export")
+	outputFlag := fs.String("output", "", "Write the exported kubeconfig to this file instead of stdout")
+	sanitizeFlag := fs.Bool("sanitize", false, "Strip all credentials, keeping servers, CAs and contexts")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	_, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	switch fs.NArg() {
+	case 0:
+		name, err = selectContext(cfg, "Select a context to export")
+		if err != nil {
+			return fmt.Errorf("selecting context: %w", err)
+		}
+	case 1:
+		name = fs.Arg(0)
+	default:
+		return fmt.Errorf("expected at most one context name, got %d", fs.NArg())
+	}
+
+	exported, err := exportContext(cfg, name)
+	if err != nil {
+		return err
+	}
+	if *sanitizeFlag {
+		sanitizeConfig(exported)
+	}
+	outData, err := kubeconfig.Marshal(exported, outputFormatFlag)
+	if err != nil {
+		return fmt.Errorf("marshaling exported config: %w", err)
+	}
+
+	if *outputFlag == "" {
+		_, err = os.Stdout.Write(outData)
+		return err
+	}
+	outputPath, err := expandPath(*outputFlag)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(outputPath, outData, 0o600); err != nil {
+		return fmt.Errorf("writing exported config: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported context %q to %s\n", name, outputPath)
+	return nil
+}
+
+// exportContext returns a standalone copy of cfg that contains only the named
+// context with its cluster and user. Referenced certificate and key files are
+// embedded so the result can be used on another machine.
+func exportContext(cfg *api.Config, name string) (*api.Config, error) {
+	if _, exists := cfg.Contexts[name]; !exists {
+		return nil, fmt.Errorf("context %s not found", name)
+	}
+	exported := cfg.DeepCopy()
+	exported.CurrentContext = name
+	if err := api.MinifyConfig(exported); err != nil {
+		return nil, fmt.Errorf("minifying config: %w", err)
+	}
+	if err := api.FlattenConfig(exported); err != nil {
+		return nil, fmt.Errorf("flattening config: %w", err)
+	}
+	return exported, nil
+}
+
+// sanitizeConfig removes all credentials from the users of cfg, so the
+// result can be shared safely. Exec and auth provider configuration is kept
+// apart from the tokens and secrets of the latter.
+func sanitizeConfig(cfg *api.Config) {
+	for _, user := range cfg.AuthInfos {
+		user.Token = ""
+		user.TokenFile = ""
+		user.ClientCertificate = ""
+		user.ClientCertificateData = nil
+		user.ClientKey = ""
+		user.ClientKeyData = nil
+		user.Username = ""
+		user.Password = ""
+		if user.AuthProvider != nil {
+			for key := range user.AuthProvider.Config {
+				if secretFieldPattern.MatchString(key) {
+					delete(user.AuthProvider.Config, key)
+				}
+			}
+		}
+	}
+}
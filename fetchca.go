@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runFetchCA(args []string) error {
+	fs, configPathFlag := newFlagSet("fetch-ca")
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print the server certificates")
+	clusterFlag := fs.String("cluster", "", "Cluster entry to store the CA in (created if missing)")
+	fs.Parse(args)
+
+	if *clusterFlag == "" {
+		return fmt.Errorf("--cluster is required")
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	cluster, exists := cfg.Clusters[*clusterFlag]
+	if !exists {
+		cluster = api.NewCluster()
+	}
+	server := cluster.Server
+	switch fs.NArg() {
+	case 0:
+		if server == "" {
+			return fmt.Errorf("cluster %s has no server, pass the server URL as argument", *clusterFlag)
+		}
+	case 1:
+		server = fs.Arg(0)
+	default:
+		return fmt.Errorf("expected at most one server URL, got %d", fs.NArg())
+	}
+
+	chain, err := fetchServerCertificates(server, cluster.TLSServerName)
+	if err != nil {
+		return err
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("server presented no certificates")
+	}
+	infof("Server %s presented %d certificate(s):\n", server, len(chain))
+	for _, cert := range chain {
+		infof("- %s\n  SHA-256 fingerprint %s\n", kubeconfig.DescribeCertificate(cert), certFingerprint(cert))
+	}
+
+	// Trust the top of the presented chain; for API servers that only send
+	// their serving certificate this pins that certificate.
+	anchor := chain[len(chain)-1]
+	infof("\nCertificate to trust: %q\nSHA-256 fingerprint: %s\n", anchor.Subject.String(), certFingerprint(anchor))
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+
+	var trust bool
+	err = runForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Trust %q with SHA-256 fingerprint %s?", anchor.Subject.String(), certFingerprint(anchor))).
+				Description("Compare the fingerprint with one obtained from a trusted source.").
+				Value(&trust),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("getting trust confirmation: %w", err)
+	}
+	if !trust {
+		infof("Aborted.\n")
+		return nil
+	}
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: anchor.Raw})
+	var changes []string
+	if !exists {
+		cfg.Clusters[*clusterFlag] = cluster
+		changes = append(changes, fmt.Sprintf("Added cluster %q with server %s", *clusterFlag, server))
+	} else if cluster.Server != server {
+		changes = append(changes, fmt.Sprintf("Updated cluster %q server from %s to %s", *clusterFlag, cluster.Server, server))
+	}
+	cluster.Server = server
+	cluster.CertificateAuthority = ""
+	cluster.InsecureSkipTLSVerify = false
+	changes = append(changes, fmt.Sprintf("Set cluster %q CA data to %s%s",
+		*clusterFlag, kubeconfig.ShortenBytes(caData), kubeconfig.CertSummary(caData)))
+	cluster.CertificateAuthorityData = caData
+
+	printChanges(changes)
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// certFingerprint returns the colon separated SHA-256 fingerprint of cert.
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runFlatten(args []string) error {
+	fs, configPathFlag := newFlagSet("flatten")
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print what would be embedded")
+	outputFlag := fs.String("output", "", "Write the flattened kubeconfig to this file instead of updating --config")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	changes, err := flattenConfig(cfg)
+	if err != nil {
+		return err
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+
+	if *outputFlag != "" {
+		outputPath, err := expandPath(*outputFlag)
+		if err != nil {
+			return err
+		}
+		outData, err := kubeconfig.Marshal(cfg, outputFormat(origData))
+		if err != nil {
+			return fmt.Errorf("marshaling flattened config: %w", err)
+		}
+		if err := ioutil.WriteFile(outputPath, outData, 0o600); err != nil {
+			return fmt.Errorf("writing flattened config: %w", err)
+		}
+		infof("Wrote flattened kubeconfig to %s\n", outputPath)
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// flattenConfig embeds all certificate-authority, client-certificate and
+// client-key file references in cfg as their -data counterparts. Relative
+// paths are resolved against the directory the entry was loaded from.
+func flattenConfig(cfg *api.Config) ([]string, error) {
+	var changes []string
+
+	var clusterNames []string
+	for name := range cfg.Clusters {
+		clusterNames = append(clusterNames, name)
+	}
+	sort.Strings(clusterNames)
+	for _, name := range clusterNames {
+		cluster := cfg.Clusters[name]
+		if cluster.CertificateAuthority == "" {
+			continue
+		}
+		path := cluster.CertificateAuthority
+		if err := api.FlattenContent(&cluster.CertificateAuthority, &cluster.CertificateAuthorityData, originDir(cluster.LocationOfOrigin)); err != nil {
+			return nil, fmt.Errorf("embedding CA of cluster %s: %w", name, err)
+		}
+		changes = append(changes, fmt.Sprintf("Embedded cluster %q CA from %s", name, path))
+	}
+
+	var userNames []string
+	for name := range cfg.AuthInfos {
+		userNames = append(userNames, name)
+	}
+	sort.Strings(userNames)
+	for _, name := range userNames {
+		user := cfg.AuthInfos[name]
+		baseDir := originDir(user.LocationOfOrigin)
+		if user.ClientCertificate != "" {
+			path := user.ClientCertificate
+			if err := api.FlattenContent(&user.ClientCertificate, &user.ClientCertificateData, baseDir); err != nil {
+				return nil, fmt.Errorf("embedding client cert of user %s: %w", name, err)
+			}
+			changes = append(changes, fmt.Sprintf("Embedded user %q client cert from %s", name, path))
+		}
+		if user.ClientKey != "" {
+			path := user.ClientKey
+			if err := api.FlattenContent(&user.ClientKey, &user.ClientKeyData, baseDir); err != nil {
+				return nil, fmt.Errorf("embedding client key of user %s: %w", name, err)
+			}
+			changes = append(changes, fmt.Sprintf("Embedded user %q client key from %s", name, path))
+		}
+	}
+	return changes, nil
+}
+
+// originDir returns the directory relative file references of an entry
+// loaded from origin are resolved against.
+func originDir(origin string) string {
+	dir, err := filepath.Abs(filepath.Dir(origin))
+	if err != nil {
+		return filepath.Dir(origin)
+	}
+	return dir
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const gcpSecretManagerAPI = "https://secretmanager.googleapis.com/v1"
+
+// gcpSecretName matches Secret Manager resource names with an optional
+// version, e.g. projects/infra/secrets/prod-kubeconfig/versions/3.
+var gcpSecretName = regexp.MustCompile(`^projects/[^/]+/secrets/[^/]+(/versions/[^/]+)?$`)
+
+// readGCPSecretKubeconfig reads the kubeconfig stored in a Google Secret
+// Manager secret, the latest version unless the name includes one. It
+// authenticates with Application Default Credentials via gcloud, as set up
+// by `gcloud auth application-default login` or GOOGLE_APPLICATION_CREDENTIALS.
+func readGCPSecretKubeconfig(name string) ([]byte, error) {
+	if !gcpSecretName.MatchString(name) {
+		return nil, fmt.Errorf("invalid secret %q, expected projects/PROJECT/secrets/NAME[/versions/VERSION]", name)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	token, err := runTool("gcloud", "auth", "application-default", "print-access-token")
+	if err != nil {
+		return nil, fmt.Errorf("getting application default credentials: %w", err)
+	}
+	body, err := httpGet(gcpSecretManagerAPI+"/"+name+":access",
+		http.Header{"Authorization": {"Bearer " + strings.TrimSpace(string(token))}})
+	if err != nil {
+		return nil, err
+	}
+	var version struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return nil, fmt.Errorf("parsing secret version: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding secret payload: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// importSource fetches kubeconfig entries from an external system. setup
+// registers the source's flags and returns the function that performs the
+// fetch once the flags have been parsed.
+type importSource struct {
+	name    string
+	usage   string
+	summary string
+	setup   func(fs *flag.FlagSet) func() (*api.Config, error)
+}
+
+var importSources = []importSource{
+	{
+		name:    "eks",
+		usage:   "eks --cluster name --region region",
+		summary: "Amazon EKS cluster via the aws CLI",
+		setup:   setupEKSImport,
+	},
+	{
+		name:    "gke",
+		usage:   "gke [--project id] [--location loc]",
+		summary: "Google GKE clusters via gcloud",
+		setup:   setupGKEImport,
+	},
+	{
+		name:    "aks",
+		usage:   "aks [--resource-group rg] [--kubelogin mode]",
+		summary: "Azure AKS clusters via the az CLI",
+		setup:   setupAKSImport,
+	},
+	{
+		name:    "doks",
+		usage:   "doks [--token token]",
+		summary: "DigitalOcean Kubernetes clusters via the API",
+		setup:   setupDOKSImport,
+	},
+	{
+		name:    "rancher",
+		usage:   "rancher --url server [--token token]",
+		summary: "Clusters managed by a Rancher server",
+		setup:   setupRancherImport,
+	},
+	{
+		name:    "openshift",
+		usage:   "openshift --server url --token token",
+		summary: "OpenShift login token (or current oc login)",
+		setup:   setupOpenShiftImport,
+	},
+	{
+		name:    "teleport",
+		usage:   "teleport",
+		summary: "Kubernetes clusters of the current tsh login",
+		setup:   setupTeleportImport,
+	},
+	{
+		name:    "tailscale",
+		usage:   "tailscale [--hostname host] [--name name]",
+		summary: "API server proxy of the Tailscale Kubernetes operator",
+		setup:   setupTailscaleImport,
+	},
+	{
+		name:    "k3s",
+		usage:   "k3s --ssh user@host [--name name]",
+		summary: "k3s server kubeconfig over SSH",
+		setup:   setupK3sImport,
+	},
+	{
+		name:    "secret",
+		usage:   "secret --name name [--namespace ns] [--context ctx]",
+		summary: "Kubeconfig stored in a Kubernetes Secret",
+		setup:   setupSecretImport,
+	},
+	{
+		name:    "capi",
+		usage:   "capi [--context ctx] [--namespace ns]",
+		summary: "Cluster API workload clusters of a management cluster",
+		setup:   setupCAPIImport,
+	},
+	{
+		name:    "rke2",
+		usage:   "rke2 --ssh user@host [--name name]",
+		summary: "RKE2 server kubeconfig over SSH",
+		setup:   setupRKE2Import,
+	},
+	{
+		name:    "talos",
+		usage:   "talos [--nodes node] [--name name]",
+		summary: "Talos cluster kubeconfig via talosctl",
+		setup:   setupTalosImport,
+	},
+	{
+		name:    "kind",
+		usage:   "kind [--internal]",
+		summary: "Local kind clusters",
+		setup:   setupKindImport,
+	},
+	{
+		name:    "minikube",
+		usage:   "minikube [--flatten]",
+		summary: "Local minikube profiles",
+		setup:   setupMinikubeImport,
+	},
+	{
+		name:    "microk8s",
+		usage:   "microk8s [--ssh user@host] [--name name]",
+		summary: "MicroK8s cluster, locally or over SSH",
+		setup:   setupMicroK8sImport,
+	},
+	{
+		name:    "vcluster",
+		usage:   "vcluster [--server url]",
+		summary: "Virtual clusters via the vcluster CLI",
+		setup:   setupVclusterImport,
+	},
+	{
+		name:    "hcloud",
+		usage:   "hcloud [--label-selector sel]",
+		summary: "k3s clusters on Hetzner Cloud servers",
+		setup:   setupHcloudImport,
+	},
+	{
+		name:    "civo",
+		usage:   "civo [--region region]",
+		summary: "Civo Kubernetes clusters via the API",
+		setup:   setupCivoImport,
+	},
+	{
+		name:    "lke",
+		usage:   "lke [--token token]",
+		summary: "Linode LKE clusters via the API",
+		setup:   setupLKEImport,
+	},
+	{
+		name:    "scaleway",
+		usage:   "scaleway [--region region] [--project id]",
+		summary: "Scaleway Kapsule clusters via the API",
+		setup:   setupScalewayImport,
+	},
+	{
+		name:    "ovh",
+		usage:   "ovh [--endpoint ovh-eu] [--project id]",
+		summary: "OVHcloud Managed Kubernetes clusters via the API",
+		setup:   setupOVHImport,
+	},
+}
+
+func runImport(args []string) error {
+	if (len(args) == 0 || strings.HasPrefix(args[0], "-")) && userSettings.ImportSource != "" {
+		args = append([]string{userSettings.ImportSource}, args...)
+	}
+	if len(args) == 0 {
+		importUsage()
+		return fmt.Errorf("missing import source")
+	}
+	var source *importSource
+	for i := range importSources {
+		if importSources[i].name == args[0] {
+			source = &importSources[i]
+		}
+	}
+	if source == nil {
+		importUsage()
+		return fmt.Errorf("unknown import source %q", args[0])
+	}
+
+	audit.source = "import " + source.name
+	fs, configPathFlag := newFlagSet("import " + source.name)
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print output")
+	addStrategyFlag(fs)
+	addRenameSuffixFlag(fs)
+	addNameTemplateFlags(fs)
+	setCurrentFlag := fs.Bool("set-current", false, "Make the imported context the current context without asking")
+	fetch := source.setup(fs)
+	fs.Parse(args[1:])
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	incoming, err := fetch()
+	if err != nil {
+		return fmt.Errorf("importing from %s: %w", source.name, err)
+	}
+	changes, err := applyNameTemplate(incoming, source.name)
+	if err != nil {
+		return err
+	}
+	dedupeChanges, err := dedupeClusters(cfg, incoming)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, dedupeChanges...)
+	renameChanges, err := renameCollisions(cfg, incoming)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, renameChanges...)
+	mergeChanges, err := kubeconfig.Merge(cfg, incoming, resolveConflict)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, mergeChanges...)
+	if len(changes) > 0 && (*setCurrentFlag || !*tryFlag) {
+		change, err := offerCurrentContext(cfg, sortedNames(incoming.Contexts), *setCurrentFlag)
+		if err != nil {
+			return err
+		}
+		if change != "" {
+			changes = append(changes, change)
+		}
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// importUsage lists the available import sources.
+func importUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s import <source> [flags]\n\nSources:\n", programName())
+	for _, source := range importSources {
+		fmt.Fprintf(os.Stderr, "  %-40s %s\n", source.usage, source.summary)
+	}
+}
+
+// runTool runs an external command and returns its standard output. The
+// command's standard error is included in the returned error on failure.
+func runTool(name string, args ...string) ([]byte, error) {
+	return runToolEnv(nil, name, args...)
+}
+
+// runToolEnv is like runTool but adds env to the command's environment.
+func runToolEnv(env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return runCommand(cmd, name)
+}
+
+// runToolInput is like runTool but feeds input to the command's standard
+// input, so secrets never have to be written to a temporary file.
+func runToolInput(input []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	return runCommand(cmd, name)
+}
+
+func runCommand(cmd *exec.Cmd, name string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running %s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("running %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// selectMany prompts for a subset of options. A single option is selected
+// without prompting.
+func selectMany(title string, options []string) ([]string, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("nothing to select from")
+	}
+	if len(options) == 1 {
+		return options, nil
+	}
+	var selected []string
+	err := runForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(title).
+				Options(huh.NewOptions(options...)...).
+				Filterable(true).
+				Value(&selected),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("nothing selected")
+	}
+	return selected, nil
+}
+
+// selectOne prompts for one of options. A single option is selected without
+// prompting.
+func selectOne(title string, options []string) (string, error) {
+	if len(options) == 0 {
+		return "", fmt.Errorf("nothing to select from")
+	}
+	if len(options) == 1 {
+		return options[0], nil
+	}
+	var selected string
+	err := runForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(title).
+				Options(huh.NewOptions(options...)...).
+				Value(&selected),
+		),
+	)
+	return selected, err
+}
+
+// addEntries copies all clusters, users and contexts of src into dst,
+// overwriting entries with the same name.
+func addEntries(dst, src *api.Config) {
+	for name, cluster := range src.Clusters {
+		dst.Clusters[name] = cluster
+	}
+	for name, user := range src.AuthInfos {
+		dst.AuthInfos[name] = user
+	}
+	for name, ctx := range src.Contexts {
+		dst.Contexts[name] = ctx
+	}
+}
+
+// httpGet fetches url with the given request headers and returns the body of
+// a successful response.
+func httpGet(url string, header http.Header) ([]byte, error) {
+	return httpDo("GET", url, header, nil)
+}
+
+// httpDo sends a request with the given headers and body and returns the
+// body of a successful (2xx) response.
+func httpDo(method, url string, header http.Header, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading response of %s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s returned %s: %s", method, url, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// tokenFromFlagOrEnv returns token if set, or the value of the first of the
+// environment variables that is set.
+func tokenFromFlagOrEnv(token string, envVars ...string) (string, error) {
+	if token != "" {
+		return token, nil
+	}
+	for _, env := range envVars {
+		if value := os.Getenv(env); value != "" {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("no API token given, use --token or set %s", strings.Join(envVars, " or "))
+}
+
+// renameImported renames the only context of cfg, together with its cluster
+// and user, to name, keeping their extensions. Distributions like k3s name
+// everything "default", which would collide with existing entries.
+func renameImported(cfg *api.Config, name string) error {
+	if len(cfg.Contexts) != 1 {
+		return fmt.Errorf("expected exactly one context to rename, found %d", len(cfg.Contexts))
+	}
+	renamed := api.NewConfig()
+	renamed.Preferences = cfg.Preferences
+	renamed.Extensions = cfg.Extensions
+	for _, ctx := range cfg.Contexts {
+		cluster, exists := cfg.Clusters[ctx.Cluster]
+		if !exists {
+			return fmt.Errorf("context references missing cluster %s", ctx.Cluster)
+		}
+		user, exists := cfg.AuthInfos[ctx.AuthInfo]
+		if !exists {
+			return fmt.Errorf("context references missing user %s", ctx.AuthInfo)
+		}
+		renamed.Clusters[name] = cluster
+		renamed.AuthInfos[name] = user
+		ctx.Cluster, ctx.AuthInfo = name, name
+		renamed.Contexts[name] = ctx
+	}
+	*cfg = *renamed
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// aksServerID is the application ID of the Azure Kubernetes Service AAD
+// server, used as audience when requesting tokens with kubelogin.
+const aksServerID = "6dae42f8-4368-4678-94ff-3960e28e3630"
+
+// aksCluster is the subset of an AKS cluster description we need.
+type aksCluster struct {
+	Name          string `json:"name"`
+	ResourceGroup string `json:"resourceGroup"`
+}
+
+func setupAKSImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	subscriptionFlag := fs.String("subscription", "", "Azure subscription (default: az CLI default)")
+	resourceGroupFlag := fs.String("resource-group", "", "Only list clusters in this resource group")
+	adminFlag := fs.Bool("admin", false, "Fetch cluster admin credentials")
+	kubeloginFlag := fs.String("kubelogin", "", "Convert Azure AD users to a kubelogin exec config with this login mode (e.g. azurecli, devicecode)")
+
+	return func() (*api.Config, error) {
+		args := []string{"aks", "list", "--output", "json"}
+		if *subscriptionFlag != "" {
+			args = append(args, "--subscription", *subscriptionFlag)
+		}
+		if *resourceGroupFlag != "" {
+			args = append(args, "--resource-group", *resourceGroupFlag)
+		}
+		out, err := runTool("az", args...)
+		if err != nil {
+			return nil, err
+		}
+		var clusters []aksCluster
+		if err := json.Unmarshal(out, &clusters); err != nil {
+			return nil, fmt.Errorf("parsing cluster list: %w", err)
+		}
+
+		byLabel := map[string]aksCluster{}
+		var labels []string
+		for _, c := range clusters {
+			label := c.ResourceGroup + "/" + c.Name
+			byLabel[label] = c
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select AKS clusters to import", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			c := byLabel[label]
+			args := []string{"aks", "get-credentials", "--name", c.Name, "--resource-group", c.ResourceGroup, "--file", "-"}
+			if *subscriptionFlag != "" {
+				args = append(args, "--subscription", *subscriptionFlag)
+			}
+			if *adminFlag {
+				args = append(args, "--admin")
+			}
+			out, err := runTool("az", args...)
+			if err != nil {
+				return nil, err
+			}
+			clusterCfg, err := clientcmd.Load(out)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of %s: %w", label, err)
+			}
+			if *kubeloginFlag != "" {
+				for _, user := range clusterCfg.AuthInfos {
+					convertToKubelogin(user, *kubeloginFlag)
+				}
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
+
+// convertToKubelogin switches user to a kubelogin exec plugin using the
+// given login mode. The server, client and tenant IDs and the environment
+// the az CLI configured, either as kubelogin arguments or as the legacy
+// azure auth provider, are kept. Users authenticating otherwise, e.g. with
+// admin credentials, are left alone.
+func convertToKubelogin(user *api.AuthInfo, loginMode string) {
+	var args []string
+	switch {
+	case user.Exec != nil && user.Exec.Command == "kubelogin":
+		args = setExecArg(user.Exec.Args, "--login", loginMode)
+	case user.AuthProvider != nil && user.AuthProvider.Name == "azure":
+		serverID := user.AuthProvider.Config["apiserver-id"]
+		if serverID == "" {
+			serverID = aksServerID
+		}
+		args = []string{"get-token", "--login", loginMode, "--server-id", serverID}
+		for _, option := range []struct{ key, arg string }{
+			{"client-id", "--client-id"}, {"tenant-id", "--tenant-id"}, {"environment", "--environment"},
+		} {
+			if value := user.AuthProvider.Config[option.key]; value != "" {
+				args = append(args, option.arg, value)
+			}
+		}
+	default:
+		debugf("user does not authenticate with Azure AD, not converting it to kubelogin")
+		return
+	}
+	user.Token = ""
+	user.AuthProvider = nil
+	user.Exec = &api.ExecConfig{
+		APIVersion:      "client.authentication.k8s.io/v1beta1",
+		Command:         "kubelogin",
+		Args:            args,
+		InstallHint:     "Install kubelogin from https://azure.github.io/kubelogin/install.html",
+		InteractiveMode: api.IfAvailableExecInteractiveMode,
+	}
+}
+
+// setExecArg returns args with the value of the option name, given as
+// "name value", "name=value" or the short form -l for --login, replaced by
+// value, or appended if missing.
+func setExecArg(args []string, name, value string) []string {
+	out := append([]string(nil), args...)
+	for i, arg := range out {
+		switch {
+		case (arg == name || (name == "--login" && arg == "-l")) && i+1 < len(out):
+			out[i+1] = value
+			return out
+		case strings.HasPrefix(arg, name+"="):
+			out[i] = name + "=" + value
+			return out
+		}
+	}
+	return append(out, name, value)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestConvertToKubelogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *api.AuthInfo
+		wantArgs []string
+	}{
+		{
+			name: "kubelogin exec",
+			user: &api.AuthInfo{Exec: &api.ExecConfig{Command: "kubelogin", Args: []string{
+				"get-token", "--environment", "AzurePublicCloud", "--server-id", "server", "--client-id", "client",
+				"--tenant-id", "tenant", "--login", "azurecli"}}},
+			wantArgs: []string{"get-token", "--environment", "AzurePublicCloud", "--server-id", "server", "--client-id", "client",
+				"--tenant-id", "tenant", "--login", "devicecode"},
+		},
+		{
+			name: "kubelogin exec without login",
+			user: &api.AuthInfo{Exec: &api.ExecConfig{Command: "kubelogin", Args: []string{
+				"get-token", "--server-id", "server", "--tenant-id", "tenant"}}},
+			wantArgs: []string{"get-token", "--server-id", "server", "--tenant-id", "tenant", "--login", "devicecode"},
+		},
+		{
+			name: "azure auth provider",
+			user: &api.AuthInfo{AuthProvider: &api.AuthProviderConfig{Name: "azure", Config: map[string]string{
+				"apiserver-id": "server", "client-id": "client", "tenant-id": "tenant", "environment": "AzurePublicCloud",
+				"access-token": "secret"}}},
+			wantArgs: []string{"get-token", "--login", "devicecode", "--server-id", "server", "--client-id", "client",
+				"--tenant-id", "tenant", "--environment", "AzurePublicCloud"},
+		},
+		{
+			name: "admin certificate",
+			user: &api.AuthInfo{ClientCertificateData: []byte("cert"), ClientKeyData: []byte("key")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convertToKubelogin(tt.user, "devicecode")
+			if tt.wantArgs == nil {
+				if tt.user.Exec != nil {
+					t.Errorf("converted to %v", tt.user.Exec.Args)
+				}
+				return
+			}
+			if tt.user.AuthProvider != nil || tt.user.Exec == nil || tt.user.Exec.Command != "kubelogin" {
+				t.Fatalf("not converted to kubelogin: %+v", tt.user)
+			}
+			if !reflect.DeepEqual(tt.user.Exec.Args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", tt.user.Exec.Args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// capiClusterList is the subset of a Cluster API cluster list we need.
+type capiClusterList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Status struct {
+			Phase string `json:"phase"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+func setupCAPIImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	contextFlag := fs.String("context", "", "Context of the management cluster (default: current context)")
+	namespaceFlag := fs.String("namespace", "", "Namespace of the Cluster objects (default: all namespaces)")
+
+	return func() (*api.Config, error) {
+		mgmt, contextName, err := loadManagementConfig(fs, *contextFlag)
+		if err != nil {
+			return nil, err
+		}
+		path := "/apis/cluster.x-k8s.io/v1beta1/clusters"
+		if *namespaceFlag != "" {
+			path = fmt.Sprintf("/apis/cluster.x-k8s.io/v1beta1/namespaces/%s/clusters", *namespaceFlag)
+		}
+		var list capiClusterList
+		if err := kubeAPIRequest(mgmt, contextName, "GET", path, nil, &list); err != nil {
+			return nil, fmt.Errorf("listing clusters: %w", err)
+		}
+
+		// Workload clusters are named after their Cluster object, qualified
+		// with the namespace where names repeat across namespaces.
+		count := map[string]int{}
+		for _, c := range list.Items {
+			count[c.Metadata.Name]++
+		}
+		type workload struct{ namespace, name, importName string }
+		byLabel := map[string]workload{}
+		var labels []string
+		for _, c := range list.Items {
+			w := workload{c.Metadata.Namespace, c.Metadata.Name, c.Metadata.Name}
+			if count[w.name] > 1 {
+				w.importName = w.namespace + "-" + w.name
+			}
+			label := fmt.Sprintf("%s/%s (%s)", w.namespace, w.name, c.Status.Phase)
+			byLabel[label] = w
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select workload clusters to import", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			w := byLabel[label]
+			// Cluster API stores the admin kubeconfig of each workload
+			// cluster in the Secret <cluster>-kubeconfig
+			data, err := readSecretKubeconfig(mgmt, contextName, w.namespace, w.name+"-kubeconfig", "value")
+			if err != nil {
+				return nil, err
+			}
+			wcfg, err := clientcmd.Load(data)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of cluster %s/%s: %w", w.namespace, w.name, err)
+			}
+			if err := renameImported(wcfg, w.importName); err != nil {
+				return nil, fmt.Errorf("cluster %s/%s: %w", w.namespace, w.name, err)
+			}
+			addEntries(cfg, wcfg)
+		}
+		return cfg, nil
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const civoAPI = "https://api.civo.com/v2"
+
+func setupCivoImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	tokenFlag := fs.String("token", "", "Civo API key (default: $CIVO_TOKEN)")
+	regionFlag := fs.String("region", "LON1", "Civo region")
+
+	return func() (*api.Config, error) {
+		token, err := tokenFromFlagOrEnv(*tokenFlag, "CIVO_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		body, err := httpGet(civoAPI+"/kubernetes/clusters?region="+url.QueryEscape(*regionFlag),
+			http.Header{"Authorization": {"Bearer " + token}})
+		if err != nil {
+			return nil, fmt.Errorf("listing clusters: %w", err)
+		}
+		var list struct {
+			Items []struct {
+				Name       string `json:"name"`
+				Kubeconfig string `json:"kubeconfig"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("parsing cluster list: %w", err)
+		}
+
+		kubeconfigs := map[string]string{}
+		var names []string
+		for _, c := range list.Items {
+			if c.Kubeconfig == "" {
+				continue
+			}
+			kubeconfigs[c.Name] = c.Kubeconfig
+			names = append(names, c.Name)
+		}
+		selected, err := selectMany("Select Civo clusters to import", names)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, name := range selected {
+			clusterCfg, err := clientcmd.Load([]byte(kubeconfigs[name]))
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of %s: %w", name, err)
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const digitalOceanAPI = "https://api.digitalocean.com/v2"
+
+// doksCluster is the subset of a DigitalOcean Kubernetes cluster description
+// we need.
+type doksCluster struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Region string `json:"region"`
+}
+
+func setupDOKSImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	tokenFlag := fs.String("token", "", "DigitalOcean API token (default: $DIGITALOCEAN_ACCESS_TOKEN)")
+
+	return func() (*api.Config, error) {
+		token, err := tokenFromFlagOrEnv(*tokenFlag, "DIGITALOCEAN_ACCESS_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		header := http.Header{"Authorization": {"Bearer " + token}}
+
+		// Follow the pages of the cluster list until there is no next one
+		var clusters []doksCluster
+		for next := digitalOceanAPI + "/kubernetes/clusters?per_page=200"; next != ""; {
+			body, err := httpGet(next, header)
+			if err != nil {
+				return nil, fmt.Errorf("listing clusters: %w", err)
+			}
+			var list struct {
+				Clusters []doksCluster `json:"kubernetes_clusters"`
+				Links    struct {
+					Pages struct {
+						Next string `json:"next"`
+					} `json:"pages"`
+				} `json:"links"`
+			}
+			if err := json.Unmarshal(body, &list); err != nil {
+				return nil, fmt.Errorf("parsing cluster list: %w", err)
+			}
+			clusters = append(clusters, list.Clusters...)
+			next = list.Links.Pages.Next
+		}
+
+		ids := map[string]string{}
+		var labels []string
+		for _, c := range clusters {
+			label := fmt.Sprintf("%s (%s)", c.Name, c.Region)
+			ids[label] = c.ID
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select DigitalOcean clusters to import", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			body, err := httpGet(fmt.Sprintf("%s/kubernetes/clusters/%s/kubeconfig", digitalOceanAPI, ids[label]), header)
+			if err != nil {
+				return nil, fmt.Errorf("fetching kubeconfig of %s: %w", label, err)
+			}
+			clusterCfg, err := clientcmd.Load(body)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of %s: %w", label, err)
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
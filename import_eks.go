@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// eksCluster is the subset of the EKS DescribeCluster response we need.
+type eksCluster struct {
+	Cluster struct {
+		Name                 string `json:"name"`
+		Arn                  string `json:"arn"`
+		Endpoint             string `json:"endpoint"`
+		CertificateAuthority struct {
+			Data string `json:"data"`
+		} `json:"certificateAuthority"`
+	} `json:"cluster"`
+}
+
+func setupEKSImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	clusterFlag := fs.String("cluster", "", "Name of the EKS cluster")
+	regionFlag := fs.String("region", "", "AWS region of the cluster")
+	profileFlag := fs.String("profile", "", "AWS CLI profile to use")
+	aliasFlag := fs.String("alias", "", "Context name (default: cluster ARN)")
+
+	return func() (*api.Config, error) {
+		if *clusterFlag == "" || *regionFlag == "" {
+			return nil, fmt.Errorf("--cluster and --region are required")
+		}
+		args := []string{"eks", "describe-cluster", "--name", *clusterFlag, "--region", *regionFlag, "--output", "json"}
+		if *profileFlag != "" {
+			args = append(args, "--profile", *profileFlag)
+		}
+		out, err := runTool("aws", args...)
+		if err != nil {
+			return nil, err
+		}
+		var described eksCluster
+		if err := json.Unmarshal(out, &described); err != nil {
+			return nil, fmt.Errorf("parsing describe-cluster output: %w", err)
+		}
+		caData, err := base64.StdEncoding.DecodeString(described.Cluster.CertificateAuthority.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding cluster CA: %w", err)
+		}
+
+		name := described.Cluster.Arn
+		contextName := *aliasFlag
+		if contextName == "" {
+			contextName = name
+		}
+
+		cluster := api.NewCluster()
+		cluster.Server = described.Cluster.Endpoint
+		cluster.CertificateAuthorityData = caData
+
+		user := api.NewAuthInfo()
+		user.Exec = &api.ExecConfig{
+			APIVersion:      "client.authentication.k8s.io/v1beta1",
+			Command:         "aws",
+			Args:            []string{"--region", *regionFlag, "eks", "get-token", "--cluster-name", *clusterFlag, "--output", "json"},
+			InteractiveMode: api.IfAvailableExecInteractiveMode,
+		}
+		if *profileFlag != "" {
+			user.Exec.Env = []api.ExecEnvVar{{Name: "AWS_PROFILE", Value: *profileFlag}}
+		}
+
+		cfg := api.NewConfig()
+		cfg.Clusters[name] = cluster
+		cfg.AuthInfos[name] = user
+		cfg.Contexts[contextName] = &api.Context{Cluster: name, AuthInfo: name}
+		return cfg, nil
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// gkeCluster is the subset of a GKE cluster description we need.
+type gkeCluster struct {
+	Name       string `json:"name"`
+	Location   string `json:"location"`
+	Endpoint   string `json:"endpoint"`
+	MasterAuth struct {
+		ClusterCACertificate string `json:"clusterCaCertificate"`
+	} `json:"masterAuth"`
+}
+
+func setupGKEImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	projectFlag := fs.String("project", "", "Google Cloud project (prompted if empty)")
+	locationFlag := fs.String("location", "", "Only list clusters in this zone or region")
+
+	return func() (*api.Config, error) {
+		project := *projectFlag
+		if project == "" {
+			out, err := runTool("gcloud", "projects", "list", "--format", "json")
+			if err != nil {
+				return nil, err
+			}
+			var projects []struct {
+				ProjectID string `json:"projectId"`
+			}
+			if err := json.Unmarshal(out, &projects); err != nil {
+				return nil, fmt.Errorf("parsing project list: %w", err)
+			}
+			var ids []string
+			for _, p := range projects {
+				ids = append(ids, p.ProjectID)
+			}
+			project, err = selectOne("Select a Google Cloud project", ids)
+			if err != nil {
+				return nil, fmt.Errorf("selecting project: %w", err)
+			}
+		}
+
+		args := []string{"container", "clusters", "list", "--project", project, "--format", "json"}
+		if *locationFlag != "" {
+			args = append(args, "--location", *locationFlag)
+		}
+		out, err := runTool("gcloud", args...)
+		if err != nil {
+			return nil, err
+		}
+		var clusters []gkeCluster
+		if err := json.Unmarshal(out, &clusters); err != nil {
+			return nil, fmt.Errorf("parsing cluster list: %w", err)
+		}
+
+		byLabel := map[string]gkeCluster{}
+		var labels []string
+		for _, c := range clusters {
+			label := fmt.Sprintf("%s (%s)", c.Name, c.Location)
+			byLabel[label] = c
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select GKE clusters to import", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			c := byLabel[label]
+			caData, err := base64.StdEncoding.DecodeString(c.MasterAuth.ClusterCACertificate)
+			if err != nil {
+				return nil, fmt.Errorf("decoding CA of cluster %s: %w", c.Name, err)
+			}
+			// Same naming scheme as gcloud container clusters get-credentials
+			name := fmt.Sprintf("gke_%s_%s_%s", project, c.Location, c.Name)
+
+			cluster := api.NewCluster()
+			cluster.Server = "https://" + c.Endpoint
+			cluster.CertificateAuthorityData = caData
+
+			user := api.NewAuthInfo()
+			user.Exec = &api.ExecConfig{
+				APIVersion:         "client.authentication.k8s.io/v1beta1",
+				Command:            "gke-gcloud-auth-plugin",
+				InstallHint:        "Install gke-gcloud-auth-plugin for use with kubectl by following https://cloud.google.com/kubernetes-engine/docs/how-to/cluster-access-for-kubectl#install_plugin",
+				ProvideClusterInfo: true,
+				InteractiveMode:    api.IfAvailableExecInteractiveMode,
+			}
+
+			cfg.Clusters[name] = cluster
+			cfg.AuthInfos[name] = user
+			cfg.Contexts[name] = &api.Context{Cluster: name, AuthInfo: name}
+		}
+		return cfg, nil
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const hetznerCloudAPI = "https://api.hetzner.cloud/v1"
+
+// hcloudServer is the subset of a Hetzner Cloud server description we need.
+type hcloudServer struct {
+	Name      string `json:"name"`
+	PublicNet struct {
+		IPv4 struct {
+			IP string `json:"ip"`
+		} `json:"ipv4"`
+	} `json:"public_net"`
+}
+
+// setupHcloudImport lists the control plane servers of a Hetzner Cloud
+// project via the API and fetches their kubeconfig over SSH. Hetzner Cloud
+// has no managed Kubernetes API; clusters on it are usually k3s based
+// (kube-hetzner, hetzner-k3s).
+func setupHcloudImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	tokenFlag := fs.String("token", "", "Hetzner Cloud API token (default: $HCLOUD_TOKEN)")
+	selectorFlag := fs.String("label-selector", "", "Only list servers matching this label selector, e.g. role=control-plane")
+	userFlag := fs.String("ssh-user", "root", "SSH user on the servers")
+	pathFlag := fs.String("path", "/etc/rancher/k3s/k3s.yaml", "Path of the kubeconfig on the servers")
+
+	return func() (*api.Config, error) {
+		token, err := tokenFromFlagOrEnv(*tokenFlag, "HCLOUD_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		// The API returns at most 50 servers per page
+		var servers []hcloudServer
+		for page := 1; page != 0; {
+			query := url.Values{"page": {strconv.Itoa(page)}, "per_page": {"50"}}
+			if *selectorFlag != "" {
+				query.Set("label_selector", *selectorFlag)
+			}
+			body, err := httpGet(hetznerCloudAPI+"/servers?"+query.Encode(), http.Header{"Authorization": {"Bearer " + token}})
+			if err != nil {
+				return nil, fmt.Errorf("listing servers: %w", err)
+			}
+			var list struct {
+				Servers []hcloudServer `json:"servers"`
+				Meta    struct {
+					Pagination struct {
+						NextPage int `json:"next_page"`
+					} `json:"pagination"`
+				} `json:"meta"`
+			}
+			if err := json.Unmarshal(body, &list); err != nil {
+				return nil, fmt.Errorf("parsing server list: %w", err)
+			}
+			servers = append(servers, list.Servers...)
+			page = list.Meta.Pagination.NextPage
+		}
+
+		ips := map[string]string{}
+		names := map[string]string{}
+		var labels []string
+		for _, s := range servers {
+			label := fmt.Sprintf("%s (%s)", s.Name, s.PublicNet.IPv4.IP)
+			ips[label] = s.PublicNet.IPv4.IP
+			names[label] = s.Name
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select control plane servers to import from", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting servers: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			nodeCfg, err := fetchNodeKubeconfig(*userFlag+"@"+ips[label], 0, *pathFlag, names[label], "")
+			if err != nil {
+				return nil, fmt.Errorf("fetching kubeconfig from %s: %w", names[label], err)
+			}
+			addEntries(cfg, nodeCfg)
+		}
+		return cfg, nil
+	}
+}
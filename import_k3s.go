@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupK3sImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	sshFlag := fs.String("ssh", "", "SSH target of a k3s server, as user@host")
+	portFlag := fs.Int("port", 0, "SSH port")
+	nameFlag := fs.String("name", "", "Name for the imported context, cluster and user (default: host)")
+	serverHostFlag := fs.String("server-host", "", "Address to replace 127.0.0.1 with (default: SSH host)")
+
+	return func() (*api.Config, error) {
+		return fetchNodeKubeconfig(*sshFlag, *portFlag, "/etc/rancher/k3s/k3s.yaml", *nameFlag, *serverHostFlag)
+	}
+}
+
+// fetchNodeKubeconfig reads the kubeconfig at path on an SSH reachable server
+// node, points its loopback server address at the node and renames its
+// entries to name.
+func fetchNodeKubeconfig(target string, port int, path, name, serverHost string) (*api.Config, error) {
+	if target == "" {
+		return nil, fmt.Errorf("--ssh is required")
+	}
+	host := sshHost(target)
+	if serverHost == "" {
+		serverHost = host
+	}
+	if name == "" {
+		name = host
+	}
+
+	out, err := sshCat(target, port, path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := clientcmd.Load(out)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s from %s: %w", path, host, err)
+	}
+	for _, change := range rewriteLoopback(cfg, serverHost) {
+		infof("%s\n", change)
+	}
+	if err := renameImported(cfg, name); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupKindImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	internalFlag := fs.Bool("internal", false, "Use the internal (container network) API server address")
+
+	return func() (*api.Config, error) {
+		out, err := runTool("kind", "get", "clusters")
+		if err != nil {
+			return nil, err
+		}
+		names := strings.Fields(string(out))
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no kind clusters found")
+		}
+		selected, err := selectMany("Select kind clusters to import", names)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, name := range selected {
+			args := []string{"get", "kubeconfig", "--name", name}
+			if *internalFlag {
+				args = append(args, "--internal")
+			}
+			out, err := runTool("kind", args...)
+			if err != nil {
+				return nil, err
+			}
+			clusterCfg, err := clientcmd.Load(out)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of kind cluster %s: %w", name, err)
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
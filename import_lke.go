@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const linodeAPI = "https://api.linode.com/v4"
+
+func setupLKEImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	tokenFlag := fs.String("token", "", "Linode API token (default: $LINODE_TOKEN)")
+
+	return func() (*api.Config, error) {
+		token, err := tokenFromFlagOrEnv(*tokenFlag, "LINODE_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		header := http.Header{"Authorization": {"Bearer " + token}}
+
+		body, err := httpGet(linodeAPI+"/lke/clusters", header)
+		if err != nil {
+			return nil, fmt.Errorf("listing clusters: %w", err)
+		}
+		var list struct {
+			Data []struct {
+				ID     int    `json:"id"`
+				Label  string `json:"label"`
+				Region string `json:"region"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("parsing cluster list: %w", err)
+		}
+
+		ids := map[string]int{}
+		var labels []string
+		for _, c := range list.Data {
+			label := fmt.Sprintf("%s (%s)", c.Label, c.Region)
+			ids[label] = c.ID
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select LKE clusters to import", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			body, err := httpGet(fmt.Sprintf("%s/lke/clusters/%d/kubeconfig", linodeAPI, ids[label]), header)
+			if err != nil {
+				return nil, fmt.Errorf("fetching kubeconfig of %s: %w", label, err)
+			}
+			var resp struct {
+				Kubeconfig string `json:"kubeconfig"`
+			}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig response of %s: %w", label, err)
+			}
+			data, err := base64.StdEncoding.DecodeString(resp.Kubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("decoding kubeconfig of %s: %w", label, err)
+			}
+			clusterCfg, err := clientcmd.Load(data)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of %s: %w", label, err)
+			}
+			if err := clientcmd.Validate(*clusterCfg); err != nil {
+				return nil, fmt.Errorf("invalid kubeconfig for %s: %w", label, err)
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupMicroK8sImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	sshFlag := fs.String("ssh", "", "SSH target of a MicroK8s node, as user@host (default: run microk8s locally)")
+	portFlag := fs.Int("port", 0, "SSH port")
+	nameFlag := fs.String("name", "", "Name for the imported context, cluster and user (prompted if empty)")
+
+	return func() (*api.Config, error) {
+		var out []byte
+		var err error
+		if *sshFlag == "" {
+			out, err = runTool("microk8s", "config")
+		} else {
+			// microk8s needs root or the microk8s group
+			out, err = sshRun(*sshFlag, *portFlag, "sudo -n microk8s config 2>/dev/null || microk8s config")
+		}
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := clientcmd.Load(out)
+		if err != nil {
+			return nil, fmt.Errorf("parsing microk8s config output: %w", err)
+		}
+		if *sshFlag != "" {
+			for _, change := range rewriteLoopback(cfg, sshHost(*sshFlag)) {
+				infof("%s\n", change)
+			}
+		}
+
+		name := *nameFlag
+		if name == "" {
+			name = "microk8s"
+			if *sshFlag != "" {
+				name = sshHost(*sshFlag)
+			}
+			err := runForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title("Enter a name for the MicroK8s cluster context").
+						Value(&name),
+				),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("getting context name: %w", err)
+			}
+		}
+		if err := renameImported(cfg, name); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+}
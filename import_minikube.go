@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// minikubeProfile is the subset of a minikube profile description we need.
+type minikubeProfile struct {
+	Name string `json:"Name"`
+}
+
+func setupMinikubeImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	flattenFlag := fs.Bool("flatten", false, "Embed the certificate files as -data fields")
+
+	return func() (*api.Config, error) {
+		out, err := runTool("minikube", "profile", "list", "--output", "json")
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Valid []minikubeProfile `json:"valid"`
+		}
+		if err := json.Unmarshal(out, &list); err != nil {
+			return nil, fmt.Errorf("parsing profile list: %w", err)
+		}
+		var names []string
+		for _, p := range list.Valid {
+			names = append(names, p.Name)
+		}
+		selected, err := selectMany("Select minikube profiles to import", names)
+		if err != nil {
+			return nil, fmt.Errorf("selecting profiles: %w", err)
+		}
+
+		// minikube keeps the entries of its profiles up to date in the
+		// default kubeconfig, with the server address reachable from the
+		// host, e.g. a forwarded port on 127.0.0.1 with the docker driver
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		source, err := loadingRules.Load()
+		if err != nil {
+			return nil, fmt.Errorf("reading the kubeconfig written by minikube: %w", err)
+		}
+		cfg := api.NewConfig()
+		for _, name := range selected {
+			ctx, exists := source.Contexts[name]
+			if !exists {
+				return nil, fmt.Errorf("no context %s in %s, run minikube update-context -p %s",
+					name, strings.Join(loadingRules.GetLoadingPrecedence(), ", "), name)
+			}
+			cluster, exists := source.Clusters[ctx.Cluster]
+			if !exists {
+				return nil, fmt.Errorf("context %s references missing cluster %s", name, ctx.Cluster)
+			}
+			user, exists := source.AuthInfos[ctx.AuthInfo]
+			if !exists {
+				return nil, fmt.Errorf("context %s references missing user %s", name, ctx.AuthInfo)
+			}
+			debugf("minikube profile %s uses server %s", name, cluster.Server)
+
+			namespace := ctx.Namespace
+			if namespace == "" {
+				namespace = "default"
+			}
+			cfg.Clusters[name] = cluster.DeepCopy()
+			cfg.AuthInfos[name] = user.DeepCopy()
+			cfg.Contexts[name] = &api.Context{Cluster: name, AuthInfo: name, Namespace: namespace, Extensions: ctx.Extensions}
+		}
+
+		if *flattenFlag {
+			if _, err := flattenConfig(cfg); err != nil {
+				return nil, err
+			}
+		}
+		return cfg, nil
+	}
+}
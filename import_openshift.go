@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupOpenShiftImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	serverFlag := fs.String("server", "", "API server URL, as shown by oc whoami --show-server")
+	tokenFlag := fs.String("token", "", "Login token, as shown by oc whoami --show-token")
+	fromOCFlag := fs.Bool("from-oc", false, "Take server and token from the current oc login")
+	contextFlag := fs.String("context", "", "Context to create or update (prompted if empty)")
+	caFileFlag := fs.String("certificate-authority", "", "File with the CA certificate of the API server")
+	insecureFlag := fs.Bool("insecure-skip-tls-verify", false, "Do not verify the API server certificate")
+
+	return func() (*api.Config, error) {
+		server, token := *serverFlag, *tokenFlag
+		if *fromOCFlag {
+			out, err := runTool("oc", "whoami", "--show-server")
+			if err != nil {
+				return nil, err
+			}
+			server = strings.TrimSpace(string(out))
+			out, err = runTool("oc", "whoami", "--show-token")
+			if err != nil {
+				return nil, err
+			}
+			token = strings.TrimSpace(string(out))
+		}
+		if server == "" || token == "" {
+			return nil, fmt.Errorf("--server and --token (or --from-oc) are required")
+		}
+
+		clusterName, err := openShiftClusterName(server)
+		if err != nil {
+			return nil, err
+		}
+		contextName := *contextFlag
+		if contextName == "" {
+			contextName = clusterName
+			err := runForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title("Enter context name").
+						Value(&contextName),
+				),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("getting context name: %w", err)
+			}
+		}
+
+		cluster := api.NewCluster()
+		cluster.Server = server
+		cluster.InsecureSkipTLSVerify = *insecureFlag
+		if *caFileFlag != "" {
+			path, err := expandPath(*caFileFlag)
+			if err != nil {
+				return nil, err
+			}
+			cluster.CertificateAuthorityData, err = ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading CA file: %w", err)
+			}
+		}
+
+		user := api.NewAuthInfo()
+		user.Token = token
+		userName := contextName + "/" + clusterName
+
+		cfg := api.NewConfig()
+		cfg.Clusters[clusterName] = cluster
+		cfg.AuthInfos[userName] = user
+		cfg.Contexts[contextName] = &api.Context{Cluster: clusterName, AuthInfo: userName}
+		return cfg, nil
+	}
+}
+
+// openShiftClusterName derives a cluster name from the server URL the same
+// way oc login does, e.g. api-example-com:6443.
+func openShiftClusterName(server string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q", server)
+	}
+	return strings.ReplaceAll(u.Host, ".", "-"), nil
+}
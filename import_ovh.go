@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ovhEndpoints maps the OVH API endpoint names to their base URLs.
+var ovhEndpoints = map[string]string{
+	"ovh-eu": "https://eu.api.ovh.com/1.0",
+	"ovh-ca": "https://ca.api.ovh.com/1.0",
+	"ovh-us": "https://api.us.ovhcloud.com/1.0",
+}
+
+// ovhClient signs requests to the OVH API with application and consumer keys.
+type ovhClient struct {
+	baseURL     string
+	appKey      string
+	appSecret   string
+	consumerKey string
+	timeDelta   int64
+}
+
+func setupOVHImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	endpointFlag := fs.String("endpoint", "", "OVH API endpoint: ovh-eu, ovh-ca or ovh-us (default: $OVH_ENDPOINT or ovh-eu)")
+	projectFlag := fs.String("project", "", "Public Cloud project ID (prompted if empty)")
+
+	return func() (*api.Config, error) {
+		endpoint := *endpointFlag
+		if endpoint == "" {
+			endpoint = os.Getenv("OVH_ENDPOINT")
+		}
+		if endpoint == "" {
+			endpoint = "ovh-eu"
+		}
+		baseURL, ok := ovhEndpoints[endpoint]
+		if !ok {
+			return nil, fmt.Errorf("unknown OVH endpoint %q", endpoint)
+		}
+		client := &ovhClient{
+			baseURL:     baseURL,
+			appKey:      os.Getenv("OVH_APPLICATION_KEY"),
+			appSecret:   os.Getenv("OVH_APPLICATION_SECRET"),
+			consumerKey: os.Getenv("OVH_CONSUMER_KEY"),
+		}
+		if client.appKey == "" || client.appSecret == "" || client.consumerKey == "" {
+			return nil, fmt.Errorf("set OVH_APPLICATION_KEY, OVH_APPLICATION_SECRET and OVH_CONSUMER_KEY")
+		}
+		if err := client.syncTime(); err != nil {
+			return nil, err
+		}
+
+		project := *projectFlag
+		if project == "" {
+			var projects []string
+			if err := client.call("GET", "/cloud/project", &projects); err != nil {
+				return nil, fmt.Errorf("listing projects: %w", err)
+			}
+			var err error
+			project, err = selectOne("Select a Public Cloud project", projects)
+			if err != nil {
+				return nil, fmt.Errorf("selecting project: %w", err)
+			}
+		}
+
+		var kubeIDs []string
+		if err := client.call("GET", "/cloud/project/"+project+"/kube", &kubeIDs); err != nil {
+			return nil, fmt.Errorf("listing clusters: %w", err)
+		}
+		ids := map[string]string{}
+		var names []string
+		for _, id := range kubeIDs {
+			var kube struct {
+				Name string `json:"name"`
+			}
+			if err := client.call("GET", "/cloud/project/"+project+"/kube/"+id, &kube); err != nil {
+				return nil, fmt.Errorf("describing cluster %s: %w", id, err)
+			}
+			label := fmt.Sprintf("%s (%s)", kube.Name, id)
+			ids[label] = id
+			names = append(names, label)
+		}
+		selected, err := selectMany("Select OVH clusters to import", names)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			var kubeconfig struct {
+				Content string `json:"content"`
+			}
+			path := "/cloud/project/" + project + "/kube/" + ids[label] + "/kubeconfig"
+			if err := client.call("POST", path, &kubeconfig); err != nil {
+				return nil, fmt.Errorf("fetching kubeconfig of %s: %w", label, err)
+			}
+			clusterCfg, err := clientcmd.Load([]byte(kubeconfig.Content))
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of %s: %w", label, err)
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
+
+// syncTime records the offset between the local clock and the API's clock,
+// since signatures are only accepted with a current timestamp.
+func (c *ovhClient) syncTime() error {
+	body, err := httpGet(c.baseURL+"/auth/time", nil)
+	if err != nil {
+		return fmt.Errorf("getting OVH API time: %w", err)
+	}
+	serverTime, err := strconv.ParseInt(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing OVH API time: %w", err)
+	}
+	c.timeDelta = serverTime - time.Now().Unix()
+	return nil
+}
+
+// call sends a signed request without body and decodes the JSON response.
+func (c *ovhClient) call(method, path string, out interface{}) error {
+	url := c.baseURL + path
+	timestamp := strconv.FormatInt(time.Now().Unix()+c.timeDelta, 10)
+	sum := sha1.Sum([]byte(strings.Join([]string{c.appSecret, c.consumerKey, method, url, "", timestamp}, "+")))
+	header := http.Header{
+		"X-Ovh-Application": {c.appKey},
+		"X-Ovh-Consumer":    {c.consumerKey},
+		"X-Ovh-Timestamp":   {timestamp},
+		"X-Ovh-Signature":   {"$1$" + hex.EncodeToString(sum[:])},
+	}
+	body, err := httpDo(method, url, header, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupRancherImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	urlFlag := fs.String("url", "", "Rancher server URL")
+	tokenFlag := fs.String("token", "", "Rancher API token (default: $RANCHER_TOKEN)")
+
+	return func() (*api.Config, error) {
+		if *urlFlag == "" {
+			return nil, fmt.Errorf("--url is required")
+		}
+		token, err := tokenFromFlagOrEnv(*tokenFlag, "RANCHER_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		server := strings.TrimSuffix(*urlFlag, "/")
+		header := http.Header{"Authorization": {"Bearer " + token}}
+
+		body, err := httpGet(server+"/v3/clusters", header)
+		if err != nil {
+			return nil, fmt.Errorf("listing clusters: %w", err)
+		}
+		var list struct {
+			Data []struct {
+				ID      string            `json:"id"`
+				Name    string            `json:"name"`
+				Actions map[string]string `json:"actions"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("parsing cluster list: %w", err)
+		}
+
+		actions := map[string]string{}
+		var labels []string
+		for _, c := range list.Data {
+			action, ok := c.Actions["generateKubeconfig"]
+			if !ok {
+				continue
+			}
+			label := fmt.Sprintf("%s (%s)", c.Name, c.ID)
+			actions[label] = action
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select Rancher clusters to import", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			body, err := httpDo("POST", actions[label], header, nil)
+			if err != nil {
+				return nil, fmt.Errorf("generating kubeconfig of %s: %w", label, err)
+			}
+			var generated struct {
+				Config string `json:"config"`
+			}
+			if err := json.Unmarshal(body, &generated); err != nil {
+				return nil, fmt.Errorf("parsing generated kubeconfig of %s: %w", label, err)
+			}
+			clusterCfg, err := clientcmd.Load([]byte(generated.Config))
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of %s: %w", label, err)
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"flag"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupRKE2Import(fs *flag.FlagSet) func() (*api.Config, error) {
+	sshFlag := fs.String("ssh", "", "SSH target of an RKE2 server, as user@host")
+	portFlag := fs.Int("port", 0, "SSH port")
+	nameFlag := fs.String("name", "", "Name for the imported context, cluster and user (default: host)")
+	serverHostFlag := fs.String("server-host", "", "Address to replace 127.0.0.1 with (default: SSH host)")
+
+	return func() (*api.Config, error) {
+		return fetchNodeKubeconfig(*sshFlag, *portFlag, "/etc/rancher/rke2/rke2.yaml", *nameFlag, *serverHostFlag)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const scalewayAPI = "https://api.scaleway.com"
+
+var scalewayRegions = []string{"fr-par", "nl-ams", "pl-waw"}
+
+func setupScalewayImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	tokenFlag := fs.String("token", "", "Scaleway secret key (default: $SCW_SECRET_KEY)")
+	regionFlag := fs.String("region", "", "Scaleway region (prompted if empty)")
+	projectFlag := fs.String("project", "", "Project ID (default: $SCW_DEFAULT_PROJECT_ID, prompted if empty)")
+
+	return func() (*api.Config, error) {
+		token, err := tokenFromFlagOrEnv(*tokenFlag, "SCW_SECRET_KEY")
+		if err != nil {
+			return nil, err
+		}
+		header := http.Header{"X-Auth-Token": {token}}
+
+		region := *regionFlag
+		if region == "" {
+			region, err = selectOne("Select a Scaleway region", scalewayRegions)
+			if err != nil {
+				return nil, fmt.Errorf("selecting region: %w", err)
+			}
+		}
+		project := *projectFlag
+		if project == "" {
+			project = os.Getenv("SCW_DEFAULT_PROJECT_ID")
+		}
+		if project == "" {
+			project, err = selectScalewayProject(header)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		regionAPI := fmt.Sprintf("%s/k8s/v1/regions/%s", scalewayAPI, region)
+		body, err := httpGet(regionAPI+"/clusters?project_id="+url.QueryEscape(project), header)
+		if err != nil {
+			return nil, fmt.Errorf("listing clusters: %w", err)
+		}
+		var list struct {
+			Clusters []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"clusters"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("parsing cluster list: %w", err)
+		}
+		ids := map[string]string{}
+		var names []string
+		for _, c := range list.Clusters {
+			ids[c.Name] = c.ID
+			names = append(names, c.Name)
+		}
+		selected, err := selectMany("Select Kapsule clusters to import", names)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, name := range selected {
+			body, err := httpGet(fmt.Sprintf("%s/clusters/%s/kubeconfig", regionAPI, ids[name]), header)
+			if err != nil {
+				return nil, fmt.Errorf("fetching kubeconfig of %s: %w", name, err)
+			}
+			var file struct {
+				Content string `json:"content"`
+			}
+			if err := json.Unmarshal(body, &file); err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig response of %s: %w", name, err)
+			}
+			data, err := base64.StdEncoding.DecodeString(file.Content)
+			if err != nil {
+				return nil, fmt.Errorf("decoding kubeconfig of %s: %w", name, err)
+			}
+			clusterCfg, err := clientcmd.Load(data)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of %s: %w", name, err)
+			}
+			addEntries(cfg, clusterCfg)
+		}
+		return cfg, nil
+	}
+}
+
+// selectScalewayProject lists the projects of the organization and prompts
+// for one of them.
+func selectScalewayProject(header http.Header) (string, error) {
+	body, err := httpGet(scalewayAPI+"/account/v3/projects", header)
+	if err != nil {
+		return "", fmt.Errorf("listing projects: %w", err)
+	}
+	var list struct {
+		Projects []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"projects"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return "", fmt.Errorf("parsing project list: %w", err)
+	}
+	ids := map[string]string{}
+	var labels []string
+	for _, p := range list.Projects {
+		label := fmt.Sprintf("%s (%s)", p.Name, p.ID)
+		ids[label] = p.ID
+		labels = append(labels, label)
+	}
+	label, err := selectOne("Select a Scaleway project", labels)
+	if err != nil {
+		return "", fmt.Errorf("selecting project: %w", err)
+	}
+	return ids[label], nil
+}
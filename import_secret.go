@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// secretKubeconfigKeys are the keys a kubeconfig is usually stored under in
+// a Secret, tried in this order. Cluster API uses value.
+var secretKubeconfigKeys = []string{"value", "kubeconfig", "config", "kubeconfig.yaml", "admin.conf"}
+
+// secret is the subset of a core/v1 Secret we need.
+type secret struct {
+	Data map[string][]byte `json:"data"`
+}
+
+func setupSecretImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	contextFlag := fs.String("context", "", "Context of the cluster holding the Secret (default: current context)")
+	namespaceFlag := fs.String("namespace", "default", "Namespace of the Secret")
+	nameFlag := fs.String("name", "", "Name of the Secret")
+	keyFlag := fs.String("key", "", "Key of the Secret holding the kubeconfig (default: "+strings.Join(secretKubeconfigKeys, ", ")+")")
+
+	return func() (*api.Config, error) {
+		if *nameFlag == "" {
+			return nil, fmt.Errorf("--name is required")
+		}
+		cfg, contextName, err := loadManagementConfig(fs, *contextFlag)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readSecretKubeconfig(cfg, contextName, *namespaceFlag, *nameFlag, *keyFlag)
+		if err != nil {
+			return nil, err
+		}
+		return clientcmd.Load(data)
+	}
+}
+
+// loadManagementConfig loads the kubeconfig given by the --config flag of fs
+// and returns it with contextName, defaulting to its current context, for
+// sources that read from a cluster already in the kubeconfig.
+func loadManagementConfig(fs *flag.FlagSet, contextName string) (*api.Config, string, error) {
+	configPath, err := expandPath(fs.Lookup("config").Value.String())
+	if err != nil {
+		return nil, "", err
+	}
+	_, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if contextName == "" {
+		contextName = cfg.CurrentContext
+	}
+	if _, exists := cfg.Contexts[contextName]; !exists {
+		return nil, "", fmt.Errorf("context %q not found in %s", contextName, configPath)
+	}
+	return cfg, contextName, nil
+}
+
+// readSecretKubeconfig reads the Secret namespace/name through the named
+// context and returns the kubeconfig stored under key, or under one of the
+// usual keys if key is empty.
+func readSecretKubeconfig(cfg *api.Config, contextName, namespace, name, key string) ([]byte, error) {
+	var s secret
+	path := fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", namespace, name)
+	if err := kubeAPIRequest(cfg, contextName, "GET", path, nil, &s); err != nil {
+		return nil, fmt.Errorf("reading secret %s/%s: %w", namespace, name, err)
+	}
+
+	keys := []string{key}
+	if key == "" {
+		keys = secretKubeconfigKeys
+		if len(s.Data) == 1 {
+			for only := range s.Data {
+				keys = []string{only}
+			}
+		}
+	}
+	for _, k := range keys {
+		if data, ok := s.Data[k]; ok {
+			debugf("using key %s of secret %s/%s", k, namespace, name)
+			return decodeInput(data, false)
+		}
+	}
+	return nil, fmt.Errorf("secret %s/%s has no key %s, it has %s", namespace, name, strings.Join(keys, " or "), quoteList(sortedNames(s.Data)))
+}
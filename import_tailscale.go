@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// tailscaleStatus is the subset of `tailscale status --json` we need.
+type tailscaleStatus struct {
+	MagicDNSSuffix string `json:"MagicDNSSuffix"`
+	Peer           map[string]struct {
+		DNSName string   `json:"DNSName"`
+		Tags    []string `json:"Tags"`
+	} `json:"Peer"`
+}
+
+// The Tailscale Kubernetes operator exposes the API server on the tailnet
+// through a proxy with a Let's Encrypt certificate. In auth mode the proxy
+// authenticates requests by tailnet identity and impersonates the caller, so
+// the kubeconfig user only carries a placeholder token, like the one
+// `tailscale configure kubeconfig` writes.
+func setupTailscaleImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	hostnameFlag := fs.String("hostname", "", "Tailnet hostname of the API server proxy (default: select among operator devices)")
+	tagFlag := fs.String("tag", "tag:k8s-operator", "Tag of the operator devices to select from")
+	nameFlag := fs.String("name", "", "Name for the imported context, cluster and user (default: hostname)")
+
+	return func() (*api.Config, error) {
+		out, err := runTool("tailscale", "status", "--json")
+		if err != nil {
+			return nil, err
+		}
+		var status tailscaleStatus
+		if err := json.Unmarshal(out, &status); err != nil {
+			return nil, fmt.Errorf("parsing tailscale status: %w", err)
+		}
+
+		fqdn := *hostnameFlag
+		if fqdn == "" {
+			var hosts []string
+			for _, peer := range status.Peer {
+				if slices.Contains(peer.Tags, *tagFlag) {
+					hosts = append(hosts, strings.TrimSuffix(peer.DNSName, "."))
+				}
+			}
+			if len(hosts) == 0 {
+				return nil, fmt.Errorf("no devices tagged %s in the tailnet, pass --hostname", *tagFlag)
+			}
+			sort.Strings(hosts)
+			if fqdn, err = selectOne("Select the API server proxy", hosts); err != nil {
+				return nil, fmt.Errorf("selecting API server proxy: %w", err)
+			}
+		} else if !strings.Contains(fqdn, ".") && status.MagicDNSSuffix != "" {
+			fqdn += "." + status.MagicDNSSuffix
+		}
+
+		name := *nameFlag
+		if name == "" {
+			name, _, _ = strings.Cut(fqdn, ".")
+		}
+		cfg := api.NewConfig()
+		cfg.Clusters[name] = &api.Cluster{Server: "https://" + fqdn}
+		cfg.AuthInfos[name] = &api.AuthInfo{Token: "unused"}
+		cfg.Contexts[name] = &api.Context{Cluster: name, AuthInfo: name}
+		return cfg, nil
+	}
+}
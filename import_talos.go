@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupTalosImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	talosconfigFlag := fs.String("talosconfig", "", "Path to the talosconfig (default: talosctl default)")
+	nodesFlag := fs.String("nodes", "", "Control plane node to fetch the kubeconfig from")
+	nameFlag := fs.String("name", "", "Name for the imported context, cluster and user (prompted if empty)")
+
+	return func() (*api.Config, error) {
+		args := []string{"kubeconfig", "-"}
+		if *talosconfigFlag != "" {
+			path, err := expandPath(*talosconfigFlag)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--talosconfig", path)
+		}
+		if *nodesFlag != "" {
+			args = append(args, "--nodes", *nodesFlag)
+		}
+		out, err := runTool("talosctl", args...)
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := clientcmd.Load(out)
+		if err != nil {
+			return nil, fmt.Errorf("parsing talosctl kubeconfig output: %w", err)
+		}
+
+		name := *nameFlag
+		if name == "" {
+			for contextName := range cfg.Contexts {
+				name = contextName
+			}
+			err := runForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title("Enter a name for the Talos cluster context").
+						Value(&name),
+				),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("getting context name: %w", err)
+			}
+		}
+		if err := renameImported(cfg, name); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func setupTeleportImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	return func() (*api.Config, error) {
+		out, err := runTool("tsh", "kube", "ls", "--format", "json")
+		if err != nil {
+			return nil, err
+		}
+		var clusters []struct {
+			Name string `json:"kube_cluster_name"`
+		}
+		if err := json.Unmarshal(out, &clusters); err != nil {
+			return nil, fmt.Errorf("parsing tsh kube ls output: %w", err)
+		}
+		var names []string
+		for _, c := range clusters {
+			names = append(names, c.Name)
+		}
+		selected, err := selectMany("Select Teleport clusters to import", names)
+		if err != nil {
+			return nil, fmt.Errorf("selecting clusters: %w", err)
+		}
+
+		// Let tsh write into a scratch kubeconfig so the result can be
+		// merged like any other source.
+		dir, err := ioutil.TempDir("", "kubeconfig-updater")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		scratch := filepath.Join(dir, "config")
+		env := []string{"KUBECONFIG=" + scratch}
+		for _, name := range selected {
+			if _, err := runToolEnv(env, "tsh", "kube", "login", name); err != nil {
+				return nil, err
+			}
+		}
+
+		data, err := ioutil.ReadFile(scratch)
+		if err != nil {
+			return nil, fmt.Errorf("reading kubeconfig written by tsh: %w", err)
+		}
+		return clientcmd.Load(data)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// vclusterInfo is the subset of a vcluster list entry we need.
+type vclusterInfo struct {
+	Name      string `json:"Name"`
+	Namespace string `json:"Namespace"`
+	Context   string `json:"Context"`
+}
+
+func setupVclusterImport(fs *flag.FlagSet) func() (*api.Config, error) {
+	serverFlag := fs.String("server", "", "Server URL to put into the vcluster kubeconfig")
+
+	return func() (*api.Config, error) {
+		out, err := runTool("vcluster", "list", "--output", "json")
+		if err != nil {
+			return nil, err
+		}
+		var vclusters []vclusterInfo
+		if err := json.Unmarshal(out, &vclusters); err != nil {
+			return nil, fmt.Errorf("parsing vcluster list: %w", err)
+		}
+		byLabel := map[string]vclusterInfo{}
+		var labels []string
+		for _, v := range vclusters {
+			label := fmt.Sprintf("%s (namespace %s on %s)", v.Name, v.Namespace, v.Context)
+			byLabel[label] = v
+			labels = append(labels, label)
+		}
+		selected, err := selectMany("Select virtual clusters to import", labels)
+		if err != nil {
+			return nil, fmt.Errorf("selecting virtual clusters: %w", err)
+		}
+
+		cfg := api.NewConfig()
+		for _, label := range selected {
+			v := byLabel[label]
+			args := []string{"connect", v.Name, "--namespace", v.Namespace, "--print"}
+			if v.Context != "" {
+				args = append(args, "--context", v.Context)
+			}
+			if *serverFlag != "" {
+				args = append(args, "--server", *serverFlag)
+			}
+			out, err := runTool("vcluster", args...)
+			if err != nil {
+				return nil, err
+			}
+			vcfg, err := clientcmd.Load(out)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig of vcluster %s: %w", v.Name, err)
+			}
+			if err := renameImported(vcfg, fmt.Sprintf("vcluster-%s-on-%s", v.Name, v.Context)); err != nil {
+				return nil, err
+			}
+			addEntries(cfg, vcfg)
+		}
+		return cfg, nil
+	}
+}
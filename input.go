@@ -0,0 +1,235 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"gopkg.in/yaml.v3"
+)
+
+// maxInputSize limits how much is read from a URL or decompressed from an
+// archive, far more than any kubeconfig needs.
+const maxInputSize = 16 << 20
+
+// base64Input matches data consisting only of base64 characters and
+// whitespace, as opposed to YAML or JSON.
+var base64Input = regexp.MustCompile(`^[A-Za-z0-9+/=_\-\s]+$`)
+
+// decodeInput turns the raw input of the update flow into kubeconfig data.
+// Input that is a base64 blob, as handed out by many providers, is
+// detected and decoded; with forceBase64 it has to be one.
+func decodeInput(data []byte, forceBase64 bool) ([]byte, error) {
+	data, err := decompressInput(data)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if !forceBase64 && !base64Input.Match(trimmed) {
+		return data, nil
+	}
+	decoded, err := decodeBase64(trimmed)
+	if err != nil {
+		if forceBase64 {
+			return nil, fmt.Errorf("decoding base64 input: %w", err)
+		}
+		return data, nil
+	}
+	if decoded, err = decompressInput(decoded); err != nil {
+		return nil, err
+	}
+	if !forceBase64 && !bytes.Contains(decoded, []byte("apiVersion")) && !bytes.Contains(decoded, []byte("clusters")) {
+		return data, nil
+	}
+	debugf("decoded %d bytes of base64 input", len(trimmed))
+	return decoded, nil
+}
+
+// decompressInput extracts gzip compressed data and the kubeconfigs in a zip
+// archive, recognized by their magic numbers. Several kubeconfigs in a zip
+// archive are returned as multi-document YAML to choose from. Any other data
+// is returned as is.
+func decompressInput(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("reading gzip input: %w", err)
+		}
+		defer r.Close()
+		decompressed, err := io.ReadAll(io.LimitReader(r, maxInputSize))
+		if err != nil {
+			return nil, fmt.Errorf("reading gzip input: %w", err)
+		}
+		debugf("decompressed %d bytes of gzip input", len(data))
+		return decompressed, nil
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return extractZip(data)
+	}
+	return data, nil
+}
+
+// extractZip returns the kubeconfigs found in a zip archive, joined as
+// multi-document YAML.
+func extractZip(data []byte) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading zip input: %w", err)
+	}
+	var found [][]byte
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || file.UncompressedSize64 > maxInputSize {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from zip input: %w", file.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(r, maxInputSize))
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from zip input: %w", file.Name, err)
+		}
+		if docs, _ := kubeconfigDocuments(content); len(docs) == 0 {
+			debugf("skipping %s in zip input, not a kubeconfig", file.Name)
+			continue
+		}
+		debugf("found kubeconfig %s in zip input", file.Name)
+		found = append(found, content)
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no kubeconfig found in zip input")
+	}
+	return bytes.Join(found, []byte("\n---\n")), nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64, with or without padding,
+// ignoring line breaks.
+func decodeBase64(data []byte) ([]byte, error) {
+	compact := bytes.Join(bytes.Fields(data), nil)
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var decoded []byte
+		if decoded, err = enc.DecodeString(string(compact)); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, err
+}
+
+// readInputFile reads the file at path, or stdin if path is "-".
+func readInputFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	path, err := expandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// readInputURL downloads the kubeconfig at url, which may also point into
+// object storage, see readObject. Responses compressed with gzip, or gzip
+// and zip files, are extracted by decodeInput.
+func readInputURL(url string) ([]byte, error) {
+	for _, scheme := range []string{"s3://", "gs://", "az://"} {
+		if strings.HasPrefix(url, scheme) {
+			return readObject(url)
+		}
+	}
+	return httpGet(url, nil)
+}
+
+// documentSeparator matches the lines separating the documents of a YAML
+// stream.
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?$`)
+
+// kubeconfigDocument is a document of a YAML stream that looks like a
+// kubeconfig, with enough of it parsed to describe it.
+type kubeconfigDocument struct {
+	data           []byte
+	index          int
+	clusters       []string
+	currentContext string
+}
+
+// kubeconfigDocuments splits a multi-document YAML stream, e.g. the output
+// of a provisioning pipeline with manifests next to the kubeconfig, and
+// returns the documents that look like kubeconfigs along with the number of
+// non-empty documents.
+func kubeconfigDocuments(data []byte) ([]kubeconfigDocument, int) {
+	var docs []kubeconfigDocument
+	total := 0
+	for _, part := range documentSeparator.Split(string(data), -1) {
+		var doc struct {
+			Kind     string `yaml:"kind"`
+			Clusters []struct {
+				Name string `yaml:"name"`
+			} `yaml:"clusters"`
+			Users          []interface{} `yaml:"users"`
+			CurrentContext string        `yaml:"current-context"`
+		}
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		total++
+		if err := yaml.Unmarshal([]byte(part), &doc); err != nil {
+			continue
+		}
+		if doc.Kind != "Config" && (len(doc.Clusters) == 0 || len(doc.Users) == 0) {
+			continue
+		}
+		kc := kubeconfigDocument{data: []byte(part), index: total, currentContext: doc.CurrentContext}
+		for _, cluster := range doc.Clusters {
+			kc.clusters = append(kc.clusters, cluster.Name)
+		}
+		docs = append(docs, kc)
+	}
+	return docs, total
+}
+
+// selectKubeconfigDocument returns the kubeconfig in a multi-document YAML
+// stream, asking which one to use if there are several. A single document
+// is returned as is.
+func selectKubeconfigDocument(data []byte) ([]byte, error) {
+	docs, total := kubeconfigDocuments(data)
+	switch {
+	case total <= 1:
+		return data, nil
+	case len(docs) == 0:
+		return nil, fmt.Errorf("none of the %d YAML documents is a kubeconfig", total)
+	case len(docs) == 1:
+		debugf("using document %d of %d, the only kubeconfig", docs[0].index, total)
+		return docs[0].data, nil
+	}
+
+	options := make([]huh.Option[int], len(docs))
+	for i, doc := range docs {
+		label := fmt.Sprintf("document %d: clusters %s", doc.index, strings.Join(doc.clusters, ", "))
+		if doc.currentContext != "" {
+			label += fmt.Sprintf(" (current context %s)", doc.currentContext)
+		}
+		options[i] = huh.NewOption(label, i)
+	}
+	var choice int
+	err := runForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title(fmt.Sprintf("The input holds %d kubeconfigs, which one should be used?", len(docs))).
+				Options(options...).
+				Value(&choice),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("selecting kubeconfig document: %w", err)
+	}
+	return docs[choice].data, nil
+}
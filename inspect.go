@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runInspect(args []string) error {
+	fs, configPathFlag := newFlagSet("inspect")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	_, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	clusterNames, userNames, err := inspectTargets(cfg, fs.Args())
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		inspectPreferences(os.Stdout, cfg)
+	}
+	for _, name := range clusterNames {
+		inspectCluster(os.Stdout, name, cfg.Clusters[name])
+	}
+	for _, name := range userNames {
+		inspectUser(os.Stdout, name, cfg.AuthInfos[name])
+	}
+	return nil
+}
+
+// inspectTargets returns the sorted cluster and user names referenced by the
+// given contexts, or all clusters and users if no context is given.
+func inspectTargets(cfg *api.Config, contextNames []string) ([]string, []string, error) {
+	clusters := map[string]bool{}
+	users := map[string]bool{}
+	if len(contextNames) == 0 {
+		for name := range cfg.Clusters {
+			clusters[name] = true
+		}
+		for name := range cfg.AuthInfos {
+			users[name] = true
+		}
+	}
+	for _, name := range contextNames {
+		ctx, exists := cfg.Contexts[name]
+		if !exists {
+			return nil, nil, fmt.Errorf("context %s not found", name)
+		}
+		if _, exists := cfg.Clusters[ctx.Cluster]; exists {
+			clusters[ctx.Cluster] = true
+		}
+		if _, exists := cfg.AuthInfos[ctx.AuthInfo]; exists {
+			users[ctx.AuthInfo] = true
+		}
+	}
+	return sortedKeys(clusters), sortedKeys(users), nil
+}
+
+// inspectCluster prints the server and CA certificates of a cluster.
+func inspectCluster(w io.Writer, name string, cluster *api.Cluster) {
+	fmt.Fprintf(w, "Cluster %q\n", name)
+	fmt.Fprintf(w, "  server: %s\n", cluster.Server)
+	if cluster.CertificateAuthority != "" {
+		fmt.Fprintf(w, "  CA file: %s\n", cluster.CertificateAuthority)
+	}
+	for _, cert := range kubeconfig.ParseCertificates(cluster.CertificateAuthorityData) {
+		fmt.Fprintf(w, "  CA: %s\n", kubeconfig.DescribeCertificate(cert))
+	}
+	if cluster.InsecureSkipTLSVerify {
+		fmt.Fprintln(w, "  insecure-skip-tls-verify: true")
+	}
+	inspectExtensions(w, "  extension ", cluster.Extensions)
+}
+
+// inspectUser prints the credentials of a user with secrets shortened.
+func inspectUser(w io.Writer, name string, user *api.AuthInfo) {
+	fmt.Fprintf(w, "User %q\n", name)
+	fmt.Fprintf(w, "  auth: %s\n", authType(user))
+	if user.Token != "" {
+		fmt.Fprintf(w, "  token: %s\n", kubeconfig.Shorten(user.Token))
+		if claims, err := kubeconfig.DecodeJWTClaims(user.Token); err == nil {
+			fmt.Fprintf(w, "  token claims: %s\n", claims)
+		}
+	}
+	if user.ClientCertificate != "" {
+		fmt.Fprintf(w, "  client cert file: %s\n", user.ClientCertificate)
+	}
+	for _, cert := range kubeconfig.ParseCertificates(user.ClientCertificateData) {
+		fmt.Fprintf(w, "  client cert: %s\n", kubeconfig.DescribeCertificate(cert))
+	}
+	if user.Exec != nil {
+		fmt.Fprintf(w, "  exec: %s %v\n", user.Exec.Command, user.Exec.Args)
+	}
+	if imp := kubeconfig.GetImpersonation(user); !imp.IsZero() {
+		fmt.Fprintf(w, "  impersonate: %s\n", imp)
+	}
+	inspectExtensions(w, "  extension ", user.Extensions)
+}
+
+// inspectPreferences prints the preferences and the top-level extensions
+// of cfg, which other tools like kubectx and cloud CLIs keep there.
+func inspectPreferences(w io.Writer, cfg *api.Config) {
+	if cfg.Preferences.Colors || len(cfg.Preferences.Extensions) > 0 {
+		fmt.Fprintln(w, "Preferences")
+		if cfg.Preferences.Colors {
+			fmt.Fprintln(w, "  colors: true")
+		}
+		inspectExtensions(w, "  ", cfg.Preferences.Extensions)
+	}
+	if len(cfg.Extensions) > 0 {
+		fmt.Fprintln(w, "Extensions")
+		inspectExtensions(w, "  ", cfg.Extensions)
+	}
+}
+
+// inspectExtensions prints the names and JSON values of extensions, each
+// line starting with prefix.
+func inspectExtensions(w io.Writer, prefix string, exts map[string]runtime.Object) {
+	for _, name := range sortedNames(exts) {
+		var value json.RawMessage
+		if _, err := kubeconfig.GetExtension(exts, name, &value); err != nil {
+			fmt.Fprintf(w, "%s%s: %v\n", prefix, name, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, name, value)
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// keychainService is the service name tokens are stored under in the OS
+// keychain. The kubeconfig user name is used as account.
+const keychainService = "kubeconfig-updater"
+
+func runStoreToken(args []string) error {
+	fs, configPathFlag := newFlagSet("store-token")
+	tryFlag := fs.Bool("try", false, "Try mode: do not touch the keychain or update file, just print output")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	_, users, err := inspectTargets(cfg, fs.Args())
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating executable for the exec stub: %w", err)
+	}
+	executable, err = filepath.Abs(executable)
+	if err != nil {
+		return err
+	}
+
+	var changes []string
+	tokens := map[string]string{}
+	for _, name := range users {
+		user := cfg.AuthInfos[name]
+		if user.Token == "" {
+			continue
+		}
+		tokens[name] = user.Token
+		user.Token = ""
+		user.Exec = &api.ExecConfig{
+			APIVersion:      "client.authentication.k8s.io/v1",
+			Command:         executable,
+			Args:            []string{"get-token", name},
+			InteractiveMode: api.NeverExecInteractiveMode,
+		}
+		changes = append(changes, fmt.Sprintf("Moved token of user %q to the keychain, user now runs %s",
+			name, kubeconfig.DescribeExec(user.Exec)))
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, name := range users {
+		token, moved := tokens[name]
+		if !moved {
+			continue
+		}
+		if err := keychainStore(name, token); err != nil {
+			return fmt.Errorf("storing token of user %s: %w", name, err)
+		}
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// runGetToken is the exec credential plugin installed by store-token. It
+// prints the token of the given user from the keychain as ExecCredential.
+func runGetToken(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one user name")
+	}
+	token, err := keychainLoad(args[0])
+	if err != nil {
+		return fmt.Errorf("reading token of user %s from the keychain: %w", args[0], err)
+	}
+	credential := map[string]interface{}{
+		"apiVersion": "client.authentication.k8s.io/v1",
+		"kind":       "ExecCredential",
+		"status":     map[string]string{"token": token},
+	}
+	return json.NewEncoder(os.Stdout).Encode(credential)
+}
@@ -0,0 +1,27 @@
+package main
+
+import "strings"
+
+// keychainStore saves secret in the macOS login keychain. The command is fed
+// to an interactive security session on standard input, so the secret never
+// shows up in the process list.
+func keychainStore(account, secret string) error {
+	command := strings.Join([]string{"add-generic-password", "-U",
+		"-s", securityQuote(keychainService), "-a", securityQuote(account), "-w", securityQuote(secret)}, " ")
+	_, err := runToolInput([]byte(command+"\n"), "security", "-i")
+	return err
+}
+
+// securityQuote quotes s as a single argument for security -i.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// keychainLoad reads a secret saved by keychainStore.
+func keychainLoad(account string) (string, error) {
+	out, err := runTool("security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
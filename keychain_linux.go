@@ -0,0 +1,18 @@
+package main
+
+// keychainStore saves secret via the Secret Service API (GNOME Keyring,
+// KWallet) using secret-tool, which reads the secret from standard input.
+func keychainStore(account, secret string) error {
+	_, err := runToolInput([]byte(secret), "secret-tool", "store", "--label", keychainService+" "+account,
+		"service", keychainService, "account", account)
+	return err
+}
+
+// keychainLoad reads a secret saved by keychainStore.
+func keychainLoad(account string) (string, error) {
+	out, err := runTool("secret-tool", "lookup", "service", keychainService, "account", account)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
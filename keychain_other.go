@@ -0,0 +1,16 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func keychainStore(account, secret string) error {
+	return fmt.Errorf("no keychain support on %s", runtime.GOOS)
+}
+
+func keychainLoad(account string) (string, error) {
+	return "", fmt.Errorf("no keychain support on %s", runtime.GOOS)
+}
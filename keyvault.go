@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// readKeyVaultKubeconfig reads the kubeconfig stored in an Azure Key Vault
+// secret, given by its identifier like
+// https://myvault.vault.azure.net/secrets/prod-kubeconfig with an optional
+// version, using the az CLI and its login.
+func readKeyVaultKubeconfig(id string) ([]byte, error) {
+	u, err := url.Parse(id)
+	if err != nil || u.Scheme != "https" || !strings.HasPrefix(u.Path, "/secrets/") {
+		return nil, fmt.Errorf("invalid secret identifier %q, expected https://VAULT.vault.azure.net/secrets/NAME", id)
+	}
+	return runTool("az", "keyvault", "secret", "show", "--id", id, "--query", "value", "--output", "tsv")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// kubeAPIRequest sends a request to the API server of the named context using
+// its credentials. If in is non-nil it is sent as JSON body; a successful JSON
+// response is decoded into out unless out is nil.
+func kubeAPIRequest(cfg *api.Config, contextName, method, path string, in, out interface{}) error {
+	restCfg, err := restConfigFor(cfg, contextName)
+	if err != nil {
+		return err
+	}
+	return restRequest(restCfg, method, path, in, out)
+}
+
+// kubeAPIError is returned for a response with a non-2xx status code.
+type kubeAPIError struct {
+	method, path string
+	status       string
+	statusCode   int
+	message      string
+}
+
+func (e *kubeAPIError) Error() string {
+	return fmt.Sprintf("%s %s returned %s: %s", e.method, e.path, e.status, e.message)
+}
+
+// restRequest sends a request like kubeAPIRequest using restCfg.
+func restRequest(restCfg *rest.Config, method, path string, in, out interface{}) error {
+	client, err := rest.HTTPClientFor(restCfg)
+	if err != nil {
+		return fmt.Errorf("creating HTTP client: %w", err)
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	url := strings.TrimSuffix(restCfg.Host, "/") + path
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", restCfg.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &kubeAPIError{method, path, resp.Status, resp.StatusCode, apiErrorMessage(data)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parsing response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// apiErrorMessage extracts the message of a Kubernetes Status response,
+// falling back to the raw body.
+func apiErrorMessage(data []byte) string {
+	var status struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &status); err == nil && status.Message != "" {
+		return status.Message
+	}
+	return strings.TrimSpace(string(data))
+}
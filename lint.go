@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// lintExpiryWarning is how long before expiry a credential is reported.
+const lintExpiryWarning = 14 * 24 * time.Hour
+
+// Lint severities, most severe first.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityInfo    = "info"
+)
+
+var severityRank = map[string]int{severityError: 0, severityWarning: 1, severityInfo: 2}
+
+// lintFinding is a problem found in a kubeconfig.
+type lintFinding struct {
+	Severity string `json:"severity"`
+	Subject  string `json:"subject"`
+	Message  string `json:"message"`
+}
+
+func runLint(args []string) error {
+	fs, configPathFlag := newFlagSet("lint")
+	jsonFlag := fs.Bool("json", false, "Print findings as JSON")
+	strictFlag := fs.Bool("strict", false, "Fail on warnings too")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	_, plain, err := readPlainConfig(configPath)
+	if err != nil {
+		return err
+	}
+	deduped, duplicates, err := removeDuplicateEntries(plain)
+	if err != nil {
+		return err
+	}
+	cfg, err := clientcmd.Load(deduped)
+	if err != nil {
+		return fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	kubeconfig.SetLocationOfOrigin(cfg, configPath)
+
+	var findings []lintFinding
+	for _, duplicate := range duplicates {
+		findings = append(findings, lintFinding{severityError, fmt.Sprintf("%s %q", duplicate.kind, duplicate.name),
+			"defined more than once, kubectl refuses to load the file (fix with normalize)"})
+	}
+	findings = append(findings, lintConfig(cfg, time.Now())...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i].Severity] < severityRank[findings[j].Severity]
+	})
+
+	counts := map[string]int{}
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+	if *jsonFlag {
+		if findings == nil {
+			findings = []lintFinding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Printf("%-8s %s: %s\n", finding.Severity, finding.Subject, finding.Message)
+		}
+		infof("%d errors, %d warnings, %d notes\n", counts[severityError], counts[severityWarning], counts[severityInfo])
+	}
+
+	if counts[severityError] > 0 || (*strictFlag && counts[severityWarning] > 0) {
+		return fmt.Errorf("%s has %d errors and %d warnings", configPath, counts[severityError], counts[severityWarning])
+	}
+	return nil
+}
+
+// lintConfig checks the contexts, clusters and users of cfg.
+func lintConfig(cfg *api.Config, now time.Time) []lintFinding {
+	var findings []lintFinding
+	add := func(severity, subject, format string, args ...interface{}) {
+		findings = append(findings, lintFinding{severity, subject, fmt.Sprintf(format, args...)})
+	}
+
+	if cfg.CurrentContext != "" {
+		if _, exists := cfg.Contexts[cfg.CurrentContext]; !exists {
+			add(severityError, "current-context", "references missing context %q", cfg.CurrentContext)
+		}
+	}
+
+	for _, name := range sortedNames(cfg.Contexts) {
+		ctx, subject := cfg.Contexts[name], fmt.Sprintf("context %q", name)
+		if _, exists := cfg.Clusters[ctx.Cluster]; !exists {
+			add(severityError, subject, "references missing cluster %q", ctx.Cluster)
+		}
+		if _, exists := cfg.AuthInfos[ctx.AuthInfo]; !exists {
+			add(severityError, subject, "references missing user %q", ctx.AuthInfo)
+		}
+	}
+
+	for _, name := range sortedNames(cfg.Clusters) {
+		cluster, subject := cfg.Clusters[name], fmt.Sprintf("cluster %q", name)
+		if u, err := url.Parse(cluster.Server); cluster.Server == "" || err != nil || u.Host == "" {
+			add(severityError, subject, "has no valid server URL (%q)", cluster.Server)
+		} else if u.Scheme == "http" {
+			add(severityWarning, subject, "uses unencrypted http:// server %s", cluster.Server)
+		}
+		if cluster.InsecureSkipTLSVerify {
+			add(severityWarning, subject, "disables TLS verification with insecure-skip-tls-verify")
+		}
+		lintFile(add, subject, "certificate-authority", originDir(cluster.LocationOfOrigin), cluster.CertificateAuthority)
+		for _, cert := range kubeconfig.ParseCertificates(cluster.CertificateAuthorityData) {
+			lintExpiry(add, subject, "CA certificate", cert.NotAfter, now)
+		}
+		if len(contextsUsingCluster(cfg, name)) == 0 {
+			add(severityInfo, subject, "is not used by any context (remove with prune)")
+		}
+	}
+
+	for _, name := range sortedNames(cfg.AuthInfos) {
+		user, subject := cfg.AuthInfos[name], fmt.Sprintf("user %q", name)
+		if !hasCredentials(user) {
+			add(severityError, subject, "has no credentials")
+		}
+		hasCert := len(user.ClientCertificateData) > 0 || user.ClientCertificate != ""
+		hasKey := len(user.ClientKeyData) > 0 || user.ClientKey != ""
+		if hasCert != hasKey {
+			add(severityError, subject, "has a client certificate or key without the other")
+		}
+		lintFile(add, subject, "client-certificate", originDir(user.LocationOfOrigin), user.ClientCertificate)
+		lintFile(add, subject, "client-key", originDir(user.LocationOfOrigin), user.ClientKey)
+		lintFile(add, subject, "tokenFile", originDir(user.LocationOfOrigin), user.TokenFile)
+		for _, cert := range kubeconfig.ParseCertificates(user.ClientCertificateData) {
+			lintExpiry(add, subject, "client certificate", cert.NotAfter, now)
+		}
+		if claims, err := kubeconfig.DecodeJWTClaims(user.Token); err == nil {
+			if exp, ok := claims.Expiry(); ok {
+				lintExpiry(add, subject, "token", exp, now)
+			}
+		}
+		if len(contextsUsingUser(cfg, name)) == 0 {
+			add(severityInfo, subject, "is not used by any context (remove with prune)")
+		}
+	}
+	return findings
+}
+
+// hasCredentials reports whether user has any way to authenticate.
+func hasCredentials(user *api.AuthInfo) bool {
+	return user.Token != "" || user.TokenFile != "" ||
+		len(user.ClientCertificateData) > 0 || user.ClientCertificate != "" ||
+		user.Exec != nil || user.AuthProvider != nil ||
+		user.Username != ""
+}
+
+// lintFile reports a referenced file that does not exist. Relative paths
+// are resolved against baseDir, the directory of the kubeconfig, as kubectl
+// does.
+func lintFile(add func(severity, subject, format string, args ...interface{}), subject, field, baseDir, path string) {
+	if path == "" {
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		add(severityError, subject, "%s file %s is not readable: %v", field, filepath.Clean(path), err)
+	}
+}
+
+// lintExpiry reports an expired credential as error and one expiring within
+// lintExpiryWarning as warning.
+func lintExpiry(add func(severity, subject, format string, args ...interface{}), subject, what string, notAfter, now time.Time) {
+	switch {
+	case now.After(notAfter):
+		add(severityError, subject, "%s expired %s ago (%s)", what, kubeconfig.FormatDays(now.Sub(notAfter)), notAfter.Format(time.RFC3339))
+	case notAfter.Sub(now) < lintExpiryWarning:
+		add(severityWarning, subject, "%s expires in %s (%s)", what, kubeconfig.FormatDays(notAfter.Sub(now)), notAfter.Format(time.RFC3339))
+	}
+}
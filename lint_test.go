@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestLintConfigResolvesRelativeFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"ca.crt", "admin.key"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	origin := filepath.Join(dir, "config")
+	cfg := api.NewConfig()
+	cfg.Clusters["prod"] = &api.Cluster{Server: "https://prod:6443", CertificateAuthority: "ca.crt", LocationOfOrigin: origin}
+	cfg.AuthInfos["admin"] = &api.AuthInfo{ClientCertificate: "certs/admin.crt", ClientKey: "admin.key", LocationOfOrigin: origin}
+	cfg.Contexts["prod"] = &api.Context{Cluster: "prod", AuthInfo: "admin"}
+
+	findings := lintConfig(cfg, time.Now())
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want only the missing client certificate", findings)
+	}
+	want := "client-certificate file " + filepath.Join(dir, "certs", "admin.crt") + " is not readable"
+	if !strings.HasPrefix(findings[0].Message, want) {
+		t.Errorf("finding = %s, want %s", findings[0].Message, want)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// contextInfo is a flattened view of a context used by the list command.
+type contextInfo struct {
+	Name        string                    `json:"name"`
+	Current     bool                      `json:"current"`
+	Cluster     string                    `json:"cluster"`
+	Server      string                    `json:"server"`
+	User        string                    `json:"user"`
+	AuthType    string                    `json:"authType"`
+	Namespace   string                    `json:"namespace,omitempty"`
+	Fingerprint string                    `json:"fingerprint,omitempty"`
+	Impersonate *kubeconfig.Impersonation `json:"impersonate,omitempty"`
+	Tags        map[string]string         `json:"tags,omitempty"`
+	LastUpdated *kubeconfig.LastUpdated   `json:"lastUpdated,omitempty"`
+}
+
+func runList(args []string) error {
+	fs, configPathFlag := newFlagSet("list")
+	jsonFlag := fs.Bool("json", false, "Print contexts as JSON")
+	tagFlag := addTagFlag(fs, "Only list contexts with this tag")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	_, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	filterContexts(cfg, *tagFlag)
+	infos := listContexts(cfg)
+	if *jsonFlag {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CURRENT\tNAME\tCLUSTER\tSERVER\tUSER\tAUTH\tNAMESPACE\tFINGERPRINT\tUPDATED\tTAGS")
+	for _, info := range infos {
+		current := ""
+		if info.Current {
+			current = "*"
+		}
+		user := info.User
+		if imp := info.Impersonate; imp != nil {
+			if imp.User != "" {
+				user += " as " + imp.User
+			} else {
+				user += " as groups " + strings.Join(imp.Groups, ",")
+			}
+		}
+		updated := ""
+		if info.LastUpdated != nil {
+			updated = kubeconfig.FormatDays(time.Since(info.LastUpdated.Time)) + " ago"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", current, info.Name, info.Cluster,
+			info.Server, user, info.AuthType, info.Namespace, info.Fingerprint, updated, kubeconfig.FormatTags(info.Tags))
+	}
+	return w.Flush()
+}
+
+// listContexts returns a sorted summary of all contexts in cfg.
+func listContexts(cfg *api.Config) []contextInfo {
+	var infos []contextInfo
+	for name, ctx := range cfg.Contexts {
+		info := contextInfo{
+			Name:      name,
+			Current:   name == cfg.CurrentContext,
+			Cluster:   ctx.Cluster,
+			User:      ctx.AuthInfo,
+			Namespace: ctx.Namespace,
+			AuthType:  "<missing>",
+		}
+		if cluster, exists := cfg.Clusters[ctx.Cluster]; exists {
+			info.Server = cluster.Server
+		}
+		if user, exists := cfg.AuthInfos[ctx.AuthInfo]; exists {
+			info.AuthType = authType(user)
+			info.Fingerprint = credentialFingerprint(user)
+			if imp := kubeconfig.GetImpersonation(user); !imp.IsZero() {
+				info.Impersonate = &imp
+			}
+		}
+		if stamp, found, err := kubeconfig.GetLastUpdated(ctx); err != nil {
+			debugf("context %q: %v", name, err)
+		} else if found {
+			info.LastUpdated = &stamp
+		}
+		if tags, err := kubeconfig.GetTags(ctx); err != nil {
+			debugf("context %q: %v", name, err)
+		} else if len(tags) > 0 {
+			info.Tags = tags
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// authType describes how a user authenticates against the cluster.
+func authType(user *api.AuthInfo) string {
+	switch {
+	case user.Exec != nil:
+		return "exec"
+	case user.AuthProvider != nil:
+		return "auth-provider"
+	case user.Token != "" || user.TokenFile != "":
+		return "token"
+	case len(user.ClientCertificateData) > 0 || user.ClientCertificate != "":
+		return "cert"
+	case user.Username != "" || user.Password != "":
+		return "basic"
+	default:
+		return "none"
+	}
+}
+
+// credentialFingerprint returns a short SHA-256 fingerprint of the user's
+// inline credential, so credentials can be compared without revealing them.
+func credentialFingerprint(user *api.AuthInfo) string {
+	var secret []byte
+	switch {
+	case user.Token != "":
+		secret = []byte(user.Token)
+	case len(user.ClientCertificateData) > 0:
+		secret = user.ClientCertificateData
+	case user.Password != "":
+		secret = []byte(user.Password)
+	default:
+		return ""
+	}
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:6])
+}
@@ -1,116 +1,168 @@
 package main
 
 import (
-	"bytes"
-	"encoding/base64"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/charmbracelet/huh"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
 
-// shorten returns a truncated version of a secret string.
-func shorten(s string) string {
-	if len(s) <= 15 {
-		return s
+func main() {
+	flag.Usage = usage
+	if err := loadSettings(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	return fmt.Sprintf("%s...%s", s[:5], s[len(s)-5:])
-}
-
-// shortenBytes base64 encodes the byte slice before shortening.
-func shortenBytes(data []byte) string {
-	if len(data) == 0 {
-		return "<empty>"
+	if pluginMode() {
+		applyKubectlConventions()
 	}
-	s := base64.StdEncoding.EncodeToString(data)
-	if len(s) <= 15 {
-		return s
+	if len(os.Args) > 1 {
+		if cmd := findCommand(os.Args[1]); cmd != nil {
+			audit.source = cmd.name
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
 	}
-	return fmt.Sprintf("%s...%s", s[:5], s[len(s)-5:])
+	runUpdate()
 }
 
-func main() {
-	configPathFlag := flag.String("config", "~/.kube/config", "Path to kubeconfig file")
+// runUpdate runs the interactive update flow that merges a pasted kubeconfig
+// into a selected context.
+func runUpdate() {
+	configPathFlag := flag.String("config", userSettings.Kubeconfig, "Path to kubeconfig file")
+	contextFlag := flag.String("context", "", "Context to update instead of selecting it interactively")
+	newContextFlag := flag.String("new-context", "", "Create this context instead of selecting one to update")
+	newClusterFlag := flag.String("new-cluster", "", "Cluster name of the context created with --new-context (default: the context name)")
+	newUserFlag := flag.String("new-user", "", "User name of the context created with --new-context (default: the context name)")
 	tryFlag := flag.Bool("try", false, "Try mode: do not update file, just print output")
+	addDebugFlags(flag.CommandLine)
+	addUIFlags(flag.CommandLine)
+	addStrategyFlag(flag.CommandLine)
+	addOutputFormatFlag(flag.CommandLine)
+	addNotifyFlag(flag.CommandLine)
+	showSecretsFlag := flag.Bool("show-secrets", false, "Print tokens and keys in full in try mode output")
+	checkCAFlag := flag.Bool("check-ca", false, "Check that the live server certificate validates against the CA before writing")
+	forceFlag := flag.Bool("force", false, "Install expired credentials or a CA failing --check-ca without asking")
+	fromVaultFlag := flag.String("from-vault", "", "Read the new kubeconfig from this Vault KV secret path instead of pasting it")
+	vaultFieldFlag := flag.String("vault-field", "kubeconfig", "Field of the Vault secret holding the kubeconfig")
+	fromOpFlag := flag.String("from-op", "", "Read the new kubeconfig or token from this 1Password secret reference (op://vault/item/field)")
+	verifyFlag := flag.Bool("verify", false, "Verify the updated context by requesting the cluster's /version endpoint")
+	namespaceFlag := flag.String("namespace", "", "Default namespace of the context instead of asking for it")
+	setCurrentFlag := flag.Bool("set-current", false, "Make the updated context the current context without asking")
+	selectFieldsFlag := flag.Bool("select-fields", false, "Choose which differing fields (server, CA, token, client cert and key) to take from the new kubeconfig")
+	updateServerFlag := flag.Bool("update-server", false, "Take the server URL from the new kubeconfig without asking")
+	fromSSMFlag := flag.String("from-ssm", "", "Read the new kubeconfig from this AWS SSM parameter (name or ARN) instead of pasting it")
+	fromAWSSecretFlag := flag.String("from-aws-secret", "", "Read the new kubeconfig or token from this AWS Secrets Manager secret (name or ARN) instead of pasting it")
+	awsSecretFieldFlag := flag.String("aws-secret-field", "", "JSON field of the AWS secret holding the kubeconfig or token (default: kubeconfig, config, value or token)")
+	fromGCPSecretFlag := flag.String("from-gcp-secret", "", "Read the new kubeconfig from this Google Secret Manager secret (projects/X/secrets/Y) instead of pasting it")
+	fromKeyVaultFlag := flag.String("from-keyvault", "", "Read the new kubeconfig from this Azure Key Vault secret URL instead of pasting it")
+	fromFileFlag := flag.String("from-file", "", "Read the new kubeconfig from this file, or - for stdin, instead of pasting it")
+	fromURLFlag := flag.String("from-url", "", "Download the new kubeconfig from this URL, or s3://, gs:// or az:// object, instead of pasting it")
+	fromSSHFlag := flag.String("from-ssh", "", "Fetch the new kubeconfig over SSH from user@host:/path instead of pasting it")
+	usePastedClusterFlag := flag.String("use-pasted-cluster", "", "Cluster of the new kubeconfig to take instead of asking when the names don't match")
+	usePastedUserFlag := flag.String("use-pasted-user", "", "User of the new kubeconfig to take instead of asking when the names don't match")
+	rewriteServerFlag := flag.String("rewrite-server", "", "Rewrite server URLs of the new kubeconfig with a sed-style s/pattern/replacement/")
+	base64Flag := flag.Bool("base64", false, "The new kubeconfig is base64 encoded (detected automatically otherwise)")
+	editorFlag := flag.Bool("editor", false, "Provide the new kubeconfig in $EDITOR instead of pasting it into the prompt")
 	flag.Parse()
 
-	// Expand tilde in the config path
-	configPath := *configPathFlag
-	if strings.HasPrefix(configPath, "~") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
-			os.Exit(1)
-		}
-		configPath = filepath.Join(home, configPath[1:])
-	}
-
-	// Read original kubeconfig content for backup
-	origData, err := ioutil.ReadFile(configPath)
+	configPath, err := expandPath(*configPathFlag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading kubeconfig file %s: %v\n", configPath, err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Parse original kubeconfig
-	origCfg, err := clientcmd.Load(origData)
+	// Read and parse original kubeconfig, keeping the raw content for backup
+	origData, origCfg, err := loadConfig(configPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing kubeconfig: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Gather context names
-	var contextNames []string
-	for name := range origCfg.Contexts {
-		contextNames = append(contextNames, name)
+	if *newContextFlag != "" {
+		if *contextFlag != "" {
+			fmt.Fprintln(os.Stderr, "Error: --context and --new-context are mutually exclusive")
+			os.Exit(1)
+		}
+		if _, exists := origCfg.Contexts[*newContextFlag]; exists {
+			fmt.Fprintf(os.Stderr, "Error: context %s already exists, use --context to update it\n", *newContextFlag)
+			os.Exit(1)
+		}
+	} else if *newClusterFlag != "" || *newUserFlag != "" {
+		fmt.Fprintln(os.Stderr, "Error: --new-cluster and --new-user require --new-context")
+		os.Exit(1)
 	}
-	contextNames = append(contextNames, "new context")
-
-	// Select context
-	var selectedContext string
-	err = huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a context to update").
-				Options(huh.NewOptions(contextNames...)...).
-				Value(&selectedContext),
-		),
-	).Run()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error selecting context: %v\n", err)
+	if err := validateNamespace(*namespaceFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Offer the existing contexts, current one first, and a new one
+	contextChoices := append(contextOptions(origCfg), huh.NewOption("new context", "new context"))
+
+	// Select context unless given on the command line
+	selectedContext := *contextFlag
+	if *newContextFlag != "" {
+		selectedContext = "new context"
+	}
+	if selectedContext == "" {
+		err = runForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title(filterTitle("Select a context to update")).
+					Options(contextChoices...).
+					Filtering(true).
+					Height(15).
+					Value(&selectedContext),
+			),
+		)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error selecting context: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	var targetContextName string
 	var targetContext *api.Context
 	var newContext bool
 
 	if selectedContext == "new context" {
 		newContext = true
-		var newCtxName, newClusterName, newUserName string
-		err = huh.NewForm(
-			huh.NewGroup(
-				huh.NewInput().
-					Title("Enter new context name").
-					Value(&newCtxName),
-				huh.NewInput().
-					Title("Enter new cluster name").
-					Value(&newClusterName),
-				huh.NewInput().
-					Title("Enter new user name").
-					Value(&newUserName),
-			),
-		).Run()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting new context details: %v\n", err)
-			os.Exit(1)
+		newCtxName, newClusterName, newUserName := *newContextFlag, *newClusterFlag, *newUserFlag
+		if newCtxName != "" {
+			// Names given on the command line, cluster and user default
+			// to the context name
+			if newClusterName == "" {
+				newClusterName = newCtxName
+			}
+			if newUserName == "" {
+				newUserName = newCtxName
+			}
+		} else {
+			err = runForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title("Enter new context name").
+						Value(&newCtxName),
+					huh.NewInput().
+						Title("Enter new cluster name").
+						Value(&newClusterName),
+					huh.NewInput().
+						Title("Enter new user name").
+						Value(&newUserName),
+				),
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error getting new context details: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
 		targetContextName = newCtxName // Set the target context name
@@ -128,70 +180,198 @@ func main() {
 		}
 	}
 
-	var updateServer bool
-	if !newContext {
-		err = huh.NewForm(
+	updateServer := *updateServerFlag
+	if *selectFieldsFlag {
+		// The server is offered along with the other differing fields
+		updateServer = true
+	}
+	if !newContext && !updateServer && canPrompt() {
+		err = runForm(
 			huh.NewGroup(
 				huh.NewConfirm().
 					Title(fmt.Sprintf("Update server URL for cluster %s?", targetContext.Cluster)).
 					Value(&updateServer),
 			),
-		).Run()
+		)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting server update confirmation: %v\n", err)
 			os.Exit(1)
 		}
 	}
 
-	// Get pasted kubeconfig
-	var pastedKubeconfig string
-	err = huh.NewForm(
-		huh.NewGroup(
-			huh.NewText().
-				Title("Paste kubeconfig (ctrl+d when done)").
-				CharLimit(99999).
-				Value(&pastedKubeconfig),
-		),
-	).Run()
+	// Get the new kubeconfig from the selected source, or let the user paste it
+	var pastedKubeconfig, sshHostName string
+	audit.context = targetContextName
+	audit.source = "update (pasted)"
+	switch {
+	case *fromVaultFlag != "":
+		audit.source = "update (vault " + *fromVaultFlag + ")"
+		data, err := readVaultKubeconfig(*fromVaultFlag, *vaultFieldFlag, targetContext.Cluster, targetContext.AuthInfo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading kubeconfig from Vault: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromOpFlag != "":
+		audit.source = "update (1password " + *fromOpFlag + ")"
+		data, err := readOnePasswordKubeconfig(*fromOpFlag, origCfg, targetContext)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading kubeconfig from 1Password: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromSSMFlag != "":
+		audit.source = "update (ssm " + *fromSSMFlag + ")"
+		data, err := readSSMKubeconfig(*fromSSMFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading kubeconfig from SSM: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromAWSSecretFlag != "":
+		audit.source = "update (aws secret " + *fromAWSSecretFlag + ")"
+		data, err := readAWSSecretKubeconfig(*fromAWSSecretFlag, *awsSecretFieldFlag, origCfg, targetContext)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading kubeconfig from AWS Secrets Manager: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromGCPSecretFlag != "":
+		audit.source = "update (gcp secret " + *fromGCPSecretFlag + ")"
+		data, err := readGCPSecretKubeconfig(*fromGCPSecretFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading kubeconfig from Google Secret Manager: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromKeyVaultFlag != "":
+		audit.source = "update (keyvault " + *fromKeyVaultFlag + ")"
+		data, err := readKeyVaultKubeconfig(*fromKeyVaultFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading kubeconfig from Azure Key Vault: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromFileFlag != "":
+		audit.source = "update (file " + *fromFileFlag + ")"
+		data, err := readInputFile(*fromFileFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading kubeconfig file: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromURLFlag != "":
+		audit.source = "update (url " + *fromURLFlag + ")"
+		data, err := readInputURL(*fromURLFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error downloading kubeconfig: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *fromSSHFlag != "":
+		audit.source = "update (ssh " + *fromSSHFlag + ")"
+		var data []byte
+		data, sshHostName, err = readSSHKubeconfig(*fromSSHFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching kubeconfig over SSH: %v\n", err)
+			os.Exit(1)
+		}
+		pastedKubeconfig = string(data)
+	case *editorFlag:
+		pastedKubeconfig, err = editKubeconfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		pastedKubeconfig, err = pasteKubeconfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading pasted kubeconfig: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	decoded, err := decodeInput([]byte(pastedKubeconfig), *base64Flag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading pasted kubeconfig: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if decoded, err = selectKubeconfigDocument(decoded); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	pastedKubeconfig = string(decoded)
+	if err := kubeconfig.Validate([]byte(pastedKubeconfig)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
 	newCfg, err := clientcmd.Load([]byte(pastedKubeconfig))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing pasted kubeconfig: %v\n", err)
 		os.Exit(1)
 	}
 
-	targetClusterName := targetContext.Cluster
-	pastedCluster, exists := newCfg.Clusters[targetClusterName]
-	if !exists {
-		var clusterOptions []string
-		for name := range newCfg.Clusters {
-			clusterOptions = append(clusterOptions, name)
+	// Point kubeconfigs fetched from a node at the node instead of localhost,
+	// then apply the explicit rewrite
+	if sshHostName != "" {
+		for _, change := range rewriteLoopback(newCfg, sshHostName) {
+			infof("%s\n", change)
 		}
-		var selectedCluster string
-		err = huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Select cluster from pasted config").
-					Options(huh.NewOptions(clusterOptions...)...).
-					Value(&selectedCluster),
-			),
-		).Run()
+	}
+	if *rewriteServerFlag != "" {
+		rewrites, err := rewriteServers(newCfg, *rewriteServerFlag)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error selecting cluster: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		for _, change := range rewrites {
+			infof("%s\n", change)
+		}
+	}
+
+	targetClusterName := targetContext.Cluster
+	pastedClusterName := targetClusterName
+	pastedCluster, exists := newCfg.Clusters[targetClusterName]
+	if exists && *usePastedClusterFlag == "" {
+		debugf("pasted config has cluster %q with the same name as the target context's cluster", targetClusterName)
+	} else {
+		selectedCluster := *usePastedClusterFlag
+		if selectedCluster != "" {
+			if _, ok := newCfg.Clusters[selectedCluster]; !ok {
+				fmt.Fprintf(os.Stderr, "Error: pasted config has no cluster %s (has %s)\n", selectedCluster, quoteList(sortedNames(newCfg.Clusters)))
+				os.Exit(1)
+			}
+		} else {
+			debugf("pasted config has no cluster named %q, asking which one to use", targetClusterName)
+			var clusterOptions []string
+			for name := range newCfg.Clusters {
+				clusterOptions = append(clusterOptions, name)
+			}
+			err = runForm(
+				huh.NewGroup(
+					huh.NewSelect[string]().
+						Title("Select cluster from pasted config").
+						Options(huh.NewOptions(clusterOptions...)...).
+						Value(&selectedCluster),
+				),
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error selecting cluster: %v\n", err)
+				os.Exit(1)
+			}
+		}
 		pastedCluster = newCfg.Clusters[selectedCluster]
-		targetContext.Cluster = selectedCluster
-		targetClusterName = selectedCluster
+		pastedClusterName = selectedCluster
+		// A cluster named with --new-cluster keeps its name
+		if *newClusterFlag == "" {
+			targetContext.Cluster = selectedCluster
+			targetClusterName = selectedCluster
+		}
 	}
 
 	var pastedContextName string
 	for name, ctx := range newCfg.Contexts {
-		if ctx.Cluster == targetClusterName {
+		if ctx.Cluster == pastedClusterName {
 			pastedContextName = name
 			break
 		}
@@ -199,107 +379,152 @@ func main() {
 	if pastedContextName == "" {
 		var ctxOptions []string
 		for name, ctx := range newCfg.Contexts {
-			if ctx.Cluster == targetClusterName {
+			if ctx.Cluster == pastedClusterName {
 				ctxOptions = append(ctxOptions, name)
 			}
 		}
 		if len(ctxOptions) == 0 {
-			fmt.Fprintf(os.Stderr, "No contexts for cluster %s in pasted config\n", targetClusterName)
+			fmt.Fprintf(os.Stderr, "No contexts for cluster %s in pasted config\n", pastedClusterName)
 			os.Exit(1)
 		}
-		err = huh.NewForm(
+		err = runForm(
 			huh.NewGroup(
 				huh.NewSelect[string]().
 					Title("Select context from pasted config").
 					Options(huh.NewOptions(ctxOptions...)...).
 					Value(&pastedContextName),
 			),
-		).Run()
+		)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error selecting context: %v\n", err)
 			os.Exit(1)
 		}
 	}
 	pastedContext := newCfg.Contexts[pastedContextName]
+	debugf("using pasted context %q with user %q", pastedContextName, pastedContext.AuthInfo)
 
 	pastedUser, exists := newCfg.AuthInfos[pastedContext.AuthInfo]
-	if !exists {
-		var userOptions []string
-		for name := range newCfg.AuthInfos {
-			userOptions = append(userOptions, name)
-		}
-		var selectedUser string
-		err = huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Select user from pasted config").
-					Options(huh.NewOptions(userOptions...)...).
-					Value(&selectedUser),
-			),
-		).Run()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error selecting user: %v\n", err)
-			os.Exit(1)
+	if !exists || *usePastedUserFlag != "" {
+		selectedUser := *usePastedUserFlag
+		if selectedUser != "" {
+			if _, ok := newCfg.AuthInfos[selectedUser]; !ok {
+				fmt.Fprintf(os.Stderr, "Error: pasted config has no user %s (has %s)\n", selectedUser, quoteList(sortedNames(newCfg.AuthInfos)))
+				os.Exit(1)
+			}
+		} else {
+			var userOptions []string
+			for name := range newCfg.AuthInfos {
+				userOptions = append(userOptions, name)
+			}
+			err = runForm(
+				huh.NewGroup(
+					huh.NewSelect[string]().
+						Title("Select user from pasted config").
+						Options(huh.NewOptions(userOptions...)...).
+						Value(&selectedUser),
+				),
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error selecting user: %v\n", err)
+				os.Exit(1)
+			}
 		}
 		pastedUser = newCfg.AuthInfos[selectedUser]
 	}
 
+	// Refuse expired credentials unless explicitly confirmed
+	if warnings := expiredCredentials(pastedUser); len(warnings) > 0 {
+		fmt.Fprintln(os.Stderr, "WARNING: the pasted kubeconfig contains expired credentials:")
+		for _, warning := range warnings {
+			fmt.Fprintln(os.Stderr, "  - "+warning)
+		}
+		confirmRisk("The pasted credentials are expired. Install them anyway?", "the pasted credentials are expired", *forceFlag)
+	}
+
+	// Update an existing cluster with the same server instead of adding a
+	// duplicate, if the user agrees
 	var changes []string
+	canonical, dedupeChanges, err := chooseCanonicalCluster(origCfg, targetClusterName, pastedCluster.Server)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if canonical != targetClusterName {
+		changes = append(changes, dedupeChanges...)
+		changes = append(changes, fmt.Sprintf("Pointed context %q at existing cluster %q instead of adding %q", targetContextName, canonical, targetClusterName))
+		targetClusterName = canonical
+		targetContext.Cluster = canonical
+	}
 
-	// Update cluster
-	existingCluster, exists := origCfg.Clusters[targetClusterName]
-	if exists {
-		if (updateServer || newContext) && existingCluster.Server != pastedCluster.Server {
-			changes = append(changes, fmt.Sprintf("Updated cluster %q server from %s to %s",
-				targetClusterName, existingCluster.Server, pastedCluster.Server))
-			existingCluster.Server = pastedCluster.Server
-		}
-		if !bytes.Equal(existingCluster.CertificateAuthorityData, pastedCluster.CertificateAuthorityData) {
-			changes = append(changes, fmt.Sprintf("Updated cluster %q CA data from %s to %s",
-				targetClusterName, shortenBytes(existingCluster.CertificateAuthorityData), shortenBytes(pastedCluster.CertificateAuthorityData)))
-			existingCluster.CertificateAuthorityData = pastedCluster.CertificateAuthorityData
+	resolve := kubeconfig.Resolver(resolveConflict)
+	if *selectFieldsFlag {
+		resolve, err = selectFields(origCfg, targetClusterName, pastedCluster, targetContext.AuthInfo, pastedUser)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-	} else {
-		origCfg.Clusters[targetClusterName] = pastedCluster
-		changes = append(changes, fmt.Sprintf("Added cluster %q with server %s and CA data %s",
-			targetClusterName, pastedCluster.Server, shortenBytes(pastedCluster.CertificateAuthorityData)))
 	}
 
+	// Update cluster
+	clusterChanges, err := kubeconfig.MergeCluster(origCfg, targetClusterName, pastedCluster, updateServer || newContext, resolve)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	changes = append(changes, clusterChanges...)
+
 	// Update user
-	targetUserName := targetContext.AuthInfo
-	existingUser, exists := origCfg.AuthInfos[targetUserName]
-	if exists {
-		if existingUser.Token != pastedUser.Token {
-			changes = append(changes, fmt.Sprintf("Updated user %q token from %s to %s",
-				targetUserName, shorten(existingUser.Token), shorten(pastedUser.Token)))
-			existingUser.Token = pastedUser.Token
-		}
-		if !bytes.Equal(existingUser.ClientCertificateData, pastedUser.ClientCertificateData) {
-			changes = append(changes, fmt.Sprintf("Updated user %q client cert from %s to %s",
-				targetUserName, shortenBytes(existingUser.ClientCertificateData), shortenBytes(pastedUser.ClientCertificateData)))
-			existingUser.ClientCertificateData = pastedUser.ClientCertificateData
-		}
-		if !bytes.Equal(existingUser.ClientKeyData, pastedUser.ClientKeyData) {
-			changes = append(changes, fmt.Sprintf("Updated user %q client key from %s to %s",
-				targetUserName, shortenBytes(existingUser.ClientKeyData), shortenBytes(pastedUser.ClientKeyData)))
-			existingUser.ClientKeyData = pastedUser.ClientKeyData
+	userChanges, err := kubeconfig.MergeUser(origCfg, targetContext.AuthInfo, pastedUser, resolve)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	changes = append(changes, userChanges...)
+
+	// Update namespace, defaulting to the one from the pasted context
+	namespace := targetContext.Namespace
+	if namespace == "" {
+		namespace = pastedContext.Namespace
+	}
+	switch {
+	case *namespaceFlag != "":
+		namespace = *namespaceFlag
+	case canPrompt():
+		namespace, err = promptNamespace(targetContextName, namespace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting namespace: %v\n", err)
+			os.Exit(1)
 		}
-	} else {
-		origCfg.AuthInfos[targetUserName] = pastedUser
-		changes = append(changes, fmt.Sprintf("Added user %q with token %s, client cert %s, and client key %s",
-			targetUserName, shorten(pastedUser.Token), shortenBytes(pastedUser.ClientCertificateData), shortenBytes(pastedUser.ClientKeyData)))
+	}
+	if change := kubeconfig.SetNamespace(targetContextName, targetContext, namespace); change != "" {
+		changes = append(changes, change)
 	}
 
-	// Print changes
-	fmt.Println("Summary of changes:")
-	if len(changes) == 0 {
-		fmt.Println("No changes made.")
-	} else {
-		for _, change := range changes {
-			fmt.Println("- " + change)
+	// Offer to switch to the context, unless just trying
+	if *setCurrentFlag || !*tryFlag {
+		change, err := offerCurrentContext(origCfg, []string{targetContextName}, *setCurrentFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if change != "" {
+			changes = append(changes, change)
 		}
 	}
 
+	// Check the CA against the live server certificate
+	if *checkCAFlag {
+		if err := verifyServerCA(origCfg.Clusters[targetClusterName]); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: CA check for cluster %q failed: %v\n", targetClusterName, err)
+			confirmRisk("The CA does not match the live server. Continue anyway?", "the CA does not match the live server", *forceFlag)
+		} else {
+			infof("CA of cluster %q matches the live server certificate\n", targetClusterName)
+		}
+	}
+
+	printChanges(changes)
+
 	// Handle try mode
 	if *tryFlag {
 		outData, err := clientcmd.Write(*origCfg)
@@ -307,28 +532,63 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error marshaling config: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("\n---- Updated kubeconfig (try mode) ----")
+		if !*showSecretsFlag {
+			outData, err = redactSecrets(outData)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if outData, err = kubeconfig.ConvertFormat(outData, outputFormat(origData)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		infof("\n---- Updated kubeconfig (try mode) ----\n")
 		fmt.Println(string(outData))
 		return
 	}
 
-	// Create backup
-	backupPath := fmt.Sprintf("%s.backup.%s", configPath, time.Now().Format(time.RFC3339))
-	if err := ioutil.WriteFile(backupPath, origData, 0o644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating backup: %v\n", err)
+	if err := saveConfig(configPath, origData, origCfg, changes); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Backup saved to %s\n", backupPath)
 
-	// Write updated config
-	outData, err := clientcmd.Write(*origCfg)
+	if *verifyFlag {
+		version, err := verifyContext(origCfg, targetContextName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Verification of context %q failed: %v\n", targetContextName, err)
+			os.Exit(1)
+		}
+		infof("Verified context %q: server version %s\n", targetContextName, version)
+	}
+}
+
+// confirmRisk asks title and exits unless the user agrees. With force it goes
+// ahead without asking; without a terminal it fails with problem, naming
+// --force.
+func confirmRisk(title, problem string, force bool) {
+	if force {
+		debugf("--force given, not asking %q", title)
+		return
+	}
+	if !canPrompt() {
+		fmt.Fprintf(os.Stderr, "Error: %s, pass --force to go ahead anyway\n", problem)
+		os.Exit(1)
+	}
+	var proceed bool
+	err := runForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(title).
+				Value(&proceed),
+		),
+	)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling updated config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error getting confirmation: %v\n", err)
 		os.Exit(1)
 	}
-	if err := ioutil.WriteFile(configPath, outData, 0o644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing updated config: %v\n", err)
+	if !proceed {
+		fmt.Fprintln(os.Stderr, "Aborted.")
 		os.Exit(1)
 	}
-	fmt.Printf("Successfully updated %s\n", configPath)
 }
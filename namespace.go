@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func runSetNamespace(args []string) error {
+	fs, configPathFlag := newFlagSet("set-namespace")
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print what would change")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var contextName, namespace string
+	switch fs.NArg() {
+	case 0:
+		contextName, err = selectContext(cfg, "Select a context to set the namespace for")
+		if err != nil {
+			return fmt.Errorf("selecting context: %w", err)
+		}
+		namespace, err = promptNamespace(contextName, cfg.Contexts[contextName].Namespace)
+		if err != nil {
+			return fmt.Errorf("getting namespace: %w", err)
+		}
+	case 2:
+		contextName, namespace = fs.Arg(0), fs.Arg(1)
+	default:
+		return fmt.Errorf("expected <context> <namespace>, got %d arguments", fs.NArg())
+	}
+
+	ctx, exists := cfg.Contexts[contextName]
+	if !exists {
+		return fmt.Errorf("context %s not found", contextName)
+	}
+	if err := validateNamespace(namespace); err != nil {
+		return err
+	}
+
+	var changes []string
+	if change := kubeconfig.SetNamespace(contextName, ctx, namespace); change != "" {
+		changes = append(changes, change)
+	}
+	printChanges(changes)
+	if *tryFlag || len(changes) == 0 {
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// validateNamespace checks that namespace is a legal namespace name. An empty
+// namespace is allowed and leaves the context on the server's default.
+func validateNamespace(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// promptNamespace asks for the namespace of contextName, prefilled with current.
+func promptNamespace(contextName, current string) (string, error) {
+	namespace := current
+	err := runForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title(fmt.Sprintf("Default namespace for context %s", contextName)).
+				Description("Leave empty to use the server default.").
+				Validate(validateNamespace).
+				Value(&namespace),
+		),
+	)
+	return namespace, err
+}
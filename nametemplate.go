@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+var (
+	nameTemplateFlag string
+	nameEnvFlag      string
+)
+
+// addNameTemplateFlags registers the --name-template and --env flags on fs.
+func addNameTemplateFlags(fs *flag.FlagSet) {
+	fs.StringVar(&nameTemplateFlag, "name-template", userSettings.NameTemplate,
+		"Template naming each incoming context with its cluster and user, e.g. '{{.Provider}}-{{.Cluster}}-{{.Env}}'")
+	fs.StringVar(&nameEnvFlag, "env", "", "Value of {{.Env}} in --name-template")
+}
+
+// nameTemplateData is what a name template can refer to, describing one
+// incoming context.
+type nameTemplateData struct {
+	Provider  string
+	Context   string
+	Cluster   string
+	User      string
+	Namespace string
+	// Host is the host name of the cluster's server.
+	Host string
+	Env  string
+}
+
+var nameTemplateFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": strings.ReplaceAll,
+}
+
+// applyNameTemplate renames every context of incoming, together with its
+// cluster and user, to the name rendered from the --name-template, so all
+// three follow the same naming convention. Clusters and users shared by
+// several contexts are copied for each of them.
+func applyNameTemplate(incoming *api.Config, provider string) ([]string, error) {
+	if nameTemplateFlag == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("name").Funcs(nameTemplateFuncs).Option("missingkey=error").Parse(nameTemplateFlag)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --name-template: %w", err)
+	}
+
+	renamed := api.NewConfig()
+	renamed.Preferences = incoming.Preferences
+	renamed.Extensions = incoming.Extensions
+	renamedFrom := map[string]string{}
+	var changes []string
+	for _, name := range sortedNames(incoming.Contexts) {
+		ctx := incoming.Contexts[name]
+		cluster, exists := incoming.Clusters[ctx.Cluster]
+		if !exists {
+			return nil, fmt.Errorf("context %s references missing cluster %s", name, ctx.Cluster)
+		}
+		user, exists := incoming.AuthInfos[ctx.AuthInfo]
+		if !exists {
+			return nil, fmt.Errorf("context %s references missing user %s", name, ctx.AuthInfo)
+		}
+		data := nameTemplateData{
+			Provider:  provider,
+			Context:   name,
+			Cluster:   ctx.Cluster,
+			User:      ctx.AuthInfo,
+			Namespace: ctx.Namespace,
+			Env:       nameEnvFlag,
+		}
+		if u, err := url.Parse(cluster.Server); err == nil {
+			data.Host = u.Hostname()
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("naming context %s: %w", name, err)
+		}
+		newName := strings.TrimSpace(buf.String())
+		if newName == "" {
+			return nil, fmt.Errorf("--name-template renders an empty name for context %s", name)
+		}
+		if other, taken := renamedFrom[newName]; taken {
+			return nil, fmt.Errorf("--name-template renders %q for both contexts %s and %s", newName, other, name)
+		}
+		renamedFrom[newName] = name
+
+		renamed.Clusters[newName] = cluster.DeepCopy()
+		renamed.AuthInfos[newName] = user.DeepCopy()
+		ctx = ctx.DeepCopy()
+		ctx.Cluster, ctx.AuthInfo = newName, newName
+		renamed.Contexts[newName] = ctx
+		if incoming.CurrentContext == name {
+			renamed.CurrentContext = newName
+		}
+		if name != newName {
+			changes = append(changes, fmt.Sprintf("Imported context %q as %q", name, newName))
+		}
+		if data.Cluster != newName {
+			changes = append(changes, fmt.Sprintf("Imported cluster %q as %q", data.Cluster, newName))
+		}
+		if data.User != newName {
+			changes = append(changes, fmt.Sprintf("Imported user %q as %q", data.User, newName))
+		}
+	}
+	*incoming = *renamed
+	return changes, nil
+}
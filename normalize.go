@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func runNormalize(args []string) error {
+	fs, configPathFlag := newFlagSet("normalize")
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print what would change")
+	checkFlag := fs.Bool("check", false, "Exit with an error if the kubeconfig is not normalized, without changing it")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	// Loading fails on duplicate names, so they are removed from the raw
+	// document first
+	origData, plain, err := readPlainConfig(configPath)
+	if err != nil {
+		return err
+	}
+	deduped, duplicates, err := removeDuplicateEntries(plain)
+	if err != nil {
+		return err
+	}
+	var changes []string
+	for _, duplicate := range duplicates {
+		if duplicate.identical {
+			changes = append(changes, fmt.Sprintf("Removed identical duplicate %s %q", duplicate.kind, duplicate.name))
+		} else {
+			changes = append(changes, fmt.Sprintf("Removed conflicting duplicate %s %q, keeping its first definition", duplicate.kind, duplicate.name))
+		}
+	}
+	cfg, err := clientcmd.Load(deduped)
+	if err != nil {
+		return fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	kubeconfig.SetLocationOfOrigin(cfg, configPath)
+
+	format := outputFormat(plain)
+	normalized, err := kubeconfig.Marshal(cfg, format)
+	if err != nil {
+		return fmt.Errorf("marshaling kubeconfig: %w", err)
+	}
+	if detected := kubeconfig.DetectFormat(plain); format != detected {
+		changes = append(changes, fmt.Sprintf("Converted from %s to %s", detected, format))
+	} else if len(changes) == 0 && !bytes.Equal(normalized, plain) {
+		changes = append(changes, "Sorted entries by name and normalized formatting")
+	}
+
+	if *checkFlag {
+		if len(changes) > 0 {
+			return fmt.Errorf("%s is not normalized: %s", configPath, strings.Join(changes, "; "))
+		}
+		infof("%s is normalized\n", configPath)
+		return nil
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// readPlainConfig reads the kubeconfig at path without parsing it, returning
+// the raw file content and its plain text, decrypted if encrypted with sops.
+func readPlainConfig(path string) ([]byte, []byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading kubeconfig file %s: %w", path, err)
+	}
+	if kubeconfig.ReadSopsMetadata(data) == nil {
+		return data, data, nil
+	}
+	plain, err := kubeconfig.DecryptSops(path)
+	return data, plain, err
+}
+
+// duplicateEntry is a cluster, user or context name defined more than once.
+type duplicateEntry struct {
+	kind, name string
+	// identical is set if the definitions are equal.
+	identical bool
+}
+
+// removeDuplicateEntries removes all but the first definition of every
+// cluster, user and context name from the kubeconfig data, which kubectl
+// would reject. It returns the remaining document and the removed entries.
+func removeDuplicateEntries(data []byte) ([]byte, []duplicateEntry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+
+	var duplicates []duplicateEntry
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		kind := map[string]string{"clusters": "cluster", "users": "user", "contexts": "context"}[root.Content[i].Value]
+		list := root.Content[i+1]
+		if kind == "" || list.Kind != yaml.SequenceNode {
+			continue
+		}
+		first := map[string]*yaml.Node{}
+		var kept []*yaml.Node
+		for _, entry := range list.Content {
+			name := entryName(entry)
+			original, seen := first[name]
+			if !seen {
+				first[name] = entry
+				kept = append(kept, entry)
+				continue
+			}
+			duplicates = append(duplicates, duplicateEntry{kind, name, sameNode(original, entry)})
+		}
+		list.Content = kept
+	}
+	if len(duplicates) == 0 {
+		return data, nil, nil
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling kubeconfig: %w", err)
+	}
+	return out, duplicates, nil
+}
+
+// entryName returns the value of the name key of a list entry.
+func entryName(entry *yaml.Node) string {
+	for i := 0; i+1 < len(entry.Content); i += 2 {
+		if entry.Content[i].Value == "name" {
+			return entry.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// sameNode reports whether two nodes hold the same data, ignoring style.
+func sameNode(a, b *yaml.Node) bool {
+	var av, bv interface{}
+	if a.Decode(&av) != nil || b.Decode(&bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestNormalizeKeepsFormat(t *testing.T) {
+	cfg := api.NewConfig()
+	cfg.Clusters["prod"] = &api.Cluster{Server: "https://prod:6443"}
+	cfg.AuthInfos["admin"] = &api.AuthInfo{Token: "token"}
+	cfg.Contexts["prod"] = &api.Context{Cluster: "prod", AuthInfo: "admin"}
+	data, err := kubeconfig.Marshal(cfg, kubeconfig.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saving appends to the audit log in the home directory
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(format string) { outputFormatFlag = format }(outputFormatFlag)
+
+	if err := runNormalize([]string{"--check", "--config", path}); err != nil {
+		t.Errorf("normalized JSON kubeconfig reported as changed: %v", err)
+	}
+	if err := runNormalize([]string{"--check", "--output-format", "yaml", "--config", path}); err == nil {
+		t.Errorf("conversion to YAML not reported as a change")
+	}
+	if err := runNormalize([]string{"--output-format", "yaml", "--config", path}); err != nil {
+		t.Fatal(err)
+	}
+	converted, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := kubeconfig.DetectFormat(converted); got != kubeconfig.FormatYAML {
+		t.Errorf("normalize --output-format yaml wrote %s", got)
+	}
+}
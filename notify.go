@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// notifyURLs are the Slack and Microsoft Teams webhooks given with --notify.
+var notifyURLs notifyTargets
+
+// notifyTargets is a repeatable flag.Value collecting webhook URLs of a
+// known chat service.
+type notifyTargets []string
+
+func (n *notifyTargets) String() string { return strings.Join(*n, ",") }
+
+func (n *notifyTargets) Set(value string) error {
+	if _, err := notifierKind(value); err != nil {
+		return err
+	}
+	*n = append(*n, value)
+	return nil
+}
+
+// addNotifyFlag registers the repeatable --notify flag on fs.
+func addNotifyFlag(fs *flag.FlagSet) {
+	fs.Var(&notifyURLs, "notify", "Post a change summary to this Slack or Microsoft Teams incoming webhook URL; repeatable")
+}
+
+// notifierKind tells the chat service of a webhook URL by its host.
+func notifierKind(webhook string) (string, error) {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Scheme != "https" {
+		return "", fmt.Errorf("invalid webhook URL %q, expected https://", webhook)
+	}
+	host := u.Hostname()
+	switch {
+	case host == "hooks.slack.com":
+		return "slack", nil
+	case strings.HasSuffix(host, ".webhook.office.com") || strings.HasSuffix(host, ".logic.azure.com") ||
+		strings.HasSuffix(host, ".powerplatform.com"):
+		return "teams", nil
+	}
+	return "", fmt.Errorf("webhook %s is neither a Slack nor a Microsoft Teams webhook", host)
+}
+
+// credentialFields are the names of user credential fields as they appear
+// in change summaries.
+const credentialFields = `token|id-token|refresh-token|client cert|client key|client-certificate-data|client-key-data|` +
+	`auth provider|auth-provider`
+
+// credentialMention matches change summaries that mention a credential
+// field, whatever they did with it.
+var credentialMention = regexp.MustCompile(`\b(?:` + credentialFields + `)\b`)
+
+// credentialChangeValues matches a change summary of a user up to the user
+// name and the field, followed by the values, which are shortened but still
+// not meant for a chat channel.
+var credentialChangeValues = regexp.MustCompile(`^(.*?\buser "(?:[^"\\]|\\.)*"(?: (?:` + credentialFields +
+	`|exec command|exec|impersonation))?)(?:[ ,(].*)?$`)
+
+// redactChange removes the credential values from a change summary. Every
+// change of a user that adds or updates it or mentions one of its credential
+// fields is cut after the user name and field.
+func redactChange(change string) string {
+	if !credentialMention.MatchString(change) && !strings.HasPrefix(change, "Updated user ") && !strings.HasPrefix(change, "Added user ") {
+		return change
+	}
+	return credentialChangeValues.ReplaceAllString(change, "$1")
+}
+
+// sendNotifications posts the changes applied to the kubeconfig at
+// configPath to the --notify webhooks. Failures only produce warnings.
+func sendNotifications(configPath string, changes []string) {
+	if len(notifyURLs) == 0 || len(changes) == 0 {
+		return
+	}
+	entry := newAuditEntry(configPath, changes)
+	title := fmt.Sprintf("kubeconfig-updater: %d changes to %s", len(changes), entry.Config)
+	if host, err := os.Hostname(); err == nil {
+		title += " on " + host
+	}
+	if entry.Source != "" {
+		title += " (" + entry.Source + ")"
+	}
+	lines := make([]string, len(changes))
+	for i, change := range changes {
+		lines[i] = "- " + redactChange(change)
+	}
+
+	for _, webhook := range notifyURLs {
+		kind, _ := notifierKind(webhook)
+		var message interface{}
+		switch kind {
+		case "slack":
+			message = map[string]string{"text": "*" + title + "*\n" + strings.Join(lines, "\n")}
+		case "teams":
+			message = teamsMessage(title, lines)
+		}
+		body, err := json.Marshal(message)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: encoding %s notification: %v\n", kind, err)
+			continue
+		}
+		debugf("posting %d changes to %s", len(changes), kind)
+		header := http.Header{"Content-Type": {"application/json"}}
+		if _, err := httpDo("POST", webhook, header, bytes.NewReader(body)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: sending %s notification: %v\n", kind, err)
+		}
+	}
+}
+
+// teamsMessage returns an Adaptive Card message as accepted by Teams
+// incoming webhooks and workflows.
+func teamsMessage(title string, lines []string) interface{} {
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{map[string]interface{}{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body": []interface{}{
+					map[string]interface{}{"type": "TextBlock", "text": title, "weight": "Bolder", "wrap": true},
+					map[string]interface{}{"type": "TextBlock", "text": strings.Join(lines, "\n"), "wrap": true},
+				},
+			},
+		}},
+	}
+}
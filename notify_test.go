@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestRedactChange(t *testing.T) {
+	tests := []struct {
+		change string
+		want   string
+	}{
+		{
+			`Updated user "admin" token from abc to def`,
+			`Updated user "admin" token`,
+		},
+		{
+			`Added user "dev" with token short, client cert <empty>, and client key <empty>`,
+			`Added user "dev"`,
+		},
+		{
+			`Kept local user "admin" token local-tok (conflicting incoming value upstream-tok)`,
+			`Kept local user "admin" token`,
+		},
+		{
+			`Kept local user "admin" client-key-data LS0tL...LS0tC (conflicting incoming value LS0tL...S0tCg)`,
+			`Kept local user "admin" client-key-data`,
+		},
+		{
+			`Updated user "oidc" id-token from eyJhb...abcde to eyJhb...fghij`,
+			`Updated user "oidc" id-token`,
+		},
+		{
+			`Moved token of user "ci" to the keychain, user now runs kubeconfig-updater keychain-credential ci`,
+			`Moved token of user "ci"`,
+		},
+		{
+			`Updated user "say \"hi\"" client key from a to b`,
+			`Updated user "say \"hi\"" client key`,
+		},
+		{
+			`Updated cluster "prod" server from https://a to https://b`,
+			`Updated cluster "prod" server from https://a to https://b`,
+		},
+		{
+			`Added context "prod" with cluster "prod" and user "admin"`,
+			`Added context "prod" with cluster "prod" and user "admin"`,
+		},
+	}
+	for _, tt := range tests {
+		if got := redactChange(tt.change); got != tt.want {
+			t.Errorf("redactChange(%s) = %s, want %s", tt.change, got, tt.want)
+		}
+	}
+}
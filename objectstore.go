@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// readObject fetches an object from S3 (s3://bucket/key), Google Cloud
+// Storage (gs://bucket/object) or Azure Blob Storage
+// (az://account/container/blob). The CLIs of the providers are used for
+// their credentials, so the usual credential chains apply.
+func readObject(location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid object URL %q", location)
+	}
+	switch u.Scheme {
+	case "s3":
+		return runTool("aws", "s3", "cp", location, "-")
+	case "gs":
+		return runTool("gcloud", "storage", "cat", location)
+	case "az":
+		return readAzureBlob(u.Host, strings.TrimPrefix(u.Path, "/"))
+	}
+	return nil, fmt.Errorf("unsupported object URL scheme %q", u.Scheme)
+}
+
+// readAzureBlob downloads the blob at path (container/blob) of a storage
+// account, authenticated with an access token of the az CLI login.
+func readAzureBlob(account, path string) ([]byte, error) {
+	token, err := runTool("az", "account", "get-access-token", "--resource", "https://storage.azure.com/",
+		"--query", "accessToken", "--output", "tsv")
+	if err != nil {
+		return nil, fmt.Errorf("getting storage access token: %w", err)
+	}
+	return httpGet(fmt.Sprintf("https://%s.blob.core.windows.net/%s", account, path), http.Header{
+		"Authorization": {"Bearer " + strings.TrimSpace(string(token))},
+		"X-Ms-Version":  {"2020-04-08"},
+	})
+}
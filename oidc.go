@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// oidcDiscovery holds the endpoints of an OpenID provider.
+type oidcDiscovery struct {
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+}
+
+// deviceAuthorization is the response to a device authorization request.
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// oidcTokens is a successful (or pending) token endpoint response.
+type oidcTokens struct {
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	Error        string `json:"error"`
+	ErrorDesc    string `json:"error_description"`
+}
+
+func runRefreshToken(args []string) error {
+	fs, configPathFlag := newFlagSet("refresh-token")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var contextName string
+	switch fs.NArg() {
+	case 0:
+		contextName, err = selectContext(cfg, "Select a context to refresh the OIDC token for")
+		if err != nil {
+			return fmt.Errorf("selecting context: %w", err)
+		}
+	case 1:
+		contextName = fs.Arg(0)
+	default:
+		return fmt.Errorf("expected at most one context name, got %d", fs.NArg())
+	}
+	ctx, exists := cfg.Contexts[contextName]
+	if !exists {
+		return fmt.Errorf("context %s not found", contextName)
+	}
+	user, exists := cfg.AuthInfos[ctx.AuthInfo]
+	if !exists {
+		return fmt.Errorf("user %s not found", ctx.AuthInfo)
+	}
+	if user.AuthProvider == nil || user.AuthProvider.Name != "oidc" {
+		return fmt.Errorf("user %s does not use the oidc auth provider", ctx.AuthInfo)
+	}
+	providerCfg := user.AuthProvider.Config
+	issuer, clientID := providerCfg["idp-issuer-url"], providerCfg["client-id"]
+	if issuer == "" || clientID == "" {
+		return fmt.Errorf("user %s is missing idp-issuer-url or client-id", ctx.AuthInfo)
+	}
+
+	scopes := []string{"openid", "offline_access"}
+	if extra := providerCfg["extra-scopes"]; extra != "" {
+		scopes = append(scopes, strings.Split(extra, ",")...)
+	}
+	tokens, err := oidcDeviceFlow(issuer, clientID, providerCfg["client-secret"], scopes)
+	if err != nil {
+		return err
+	}
+
+	changes := []string{fmt.Sprintf("Updated user %q id-token from %s to %s",
+		ctx.AuthInfo, kubeconfig.DescribeToken(providerCfg["id-token"]), kubeconfig.DescribeToken(tokens.IDToken))}
+	providerCfg["id-token"] = tokens.IDToken
+	if tokens.RefreshToken != "" {
+		changes = append(changes, fmt.Sprintf("Updated user %q refresh-token from %s to %s",
+			ctx.AuthInfo, kubeconfig.Shorten(providerCfg["refresh-token"]), kubeconfig.Shorten(tokens.RefreshToken)))
+		providerCfg["refresh-token"] = tokens.RefreshToken
+	}
+	printChanges(changes)
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// oidcDeviceFlow runs the OAuth 2.0 device authorization grant against the
+// issuer and returns the tokens once the user approved the request.
+func oidcDeviceFlow(issuer, clientID, clientSecret string, scopes []string) (*oidcTokens, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var discovery oidcDiscovery
+	if err := getJSON(client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("discovering OIDC endpoints: %w", err)
+	}
+	if discovery.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s does not support the device authorization flow", issuer)
+	}
+
+	form := url.Values{"client_id": {clientID}, "scope": {strings.Join(scopes, " ")}}
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+	var auth deviceAuthorization
+	if err := postFormJSON(client, discovery.DeviceAuthorizationEndpoint, form, &auth); err != nil {
+		return nil, fmt.Errorf("requesting device authorization: %w", err)
+	}
+
+	if auth.VerificationURIComplete != "" {
+		fmt.Printf("Open %s to log in (code %s)\n", auth.VerificationURIComplete, auth.UserCode)
+	} else {
+		fmt.Printf("Open %s and enter the code %s\n", auth.VerificationURI, auth.UserCode)
+	}
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	form = url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {auth.DeviceCode},
+		"client_id":   {clientID},
+	}
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+	for auth.ExpiresIn == 0 || time.Now().Before(deadline) {
+		time.Sleep(interval)
+		var tokens oidcTokens
+		if err := postFormJSON(client, discovery.TokenEndpoint, form, &tokens); err != nil {
+			return nil, fmt.Errorf("polling token endpoint: %w", err)
+		}
+		switch tokens.Error {
+		case "":
+			if tokens.IDToken == "" {
+				return nil, fmt.Errorf("token response contains no id_token")
+			}
+			return &tokens, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, fmt.Errorf("device login failed: %s %s", tokens.Error, tokens.ErrorDesc)
+		}
+	}
+	return nil, fmt.Errorf("device code expired before the login was completed")
+}
+
+// getJSON fetches endpoint and decodes the JSON response into v.
+func getJSON(client *http.Client, endpoint string, v interface{}) error {
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// postFormJSON posts form to endpoint and decodes the JSON response into v. OAuth
+// error responses are decoded as well, so callers can inspect the error code.
+func postFormJSON(client *http.Client, endpoint string, form url.Values, v interface{}) error {
+	resp, err := client.PostForm(endpoint, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
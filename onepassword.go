@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// readOnePasswordKubeconfig reads a secret reference like
+// op://vault/item/field with the 1Password CLI. A field holding a kubeconfig
+// is returned as is. Any other value is taken as bearer token for the user of
+// target, combined with its current cluster entry.
+func readOnePasswordKubeconfig(ref string, cfg *api.Config, target *api.Context) ([]byte, error) {
+	if !strings.HasPrefix(ref, "op://") {
+		return nil, fmt.Errorf("invalid secret reference %q, expected op://vault/item/field", ref)
+	}
+	out, err := runTool("op", "read", "--no-newline", ref)
+	if err != nil {
+		return nil, err
+	}
+	return kubeconfigOrToken(out, cfg, target)
+}
+
+// kubeconfigOrToken returns value as is if it holds a kubeconfig, plain or
+// base64 encoded. Any other value is taken as bearer token for the user of
+// target, see tokenKubeconfig.
+func kubeconfigOrToken(value []byte, cfg *api.Config, target *api.Context) ([]byte, error) {
+	if decoded, err := decodeInput(value, false); err == nil {
+		if incoming, err := clientcmd.Load(decoded); err == nil && len(incoming.Clusters) > 0 {
+			return value, nil
+		}
+	}
+	return tokenKubeconfig(cfg, target, strings.TrimSpace(string(value)))
+}
+
+// tokenKubeconfig builds a kubeconfig that carries the existing cluster of
+// target and its user with token as credential, for sources that only
+// provide a token.
+func tokenKubeconfig(cfg *api.Config, target *api.Context, token string) ([]byte, error) {
+	cluster, exists := cfg.Clusters[target.Cluster]
+	if !exists {
+		return nil, fmt.Errorf("got a token only, but cluster %s does not exist yet", target.Cluster)
+	}
+	user := api.NewAuthInfo()
+	if existing, exists := cfg.AuthInfos[target.AuthInfo]; exists {
+		user = existing.DeepCopy()
+	}
+	user.Token = token
+
+	incoming := api.NewConfig()
+	incoming.Clusters[target.Cluster] = cluster.DeepCopy()
+	incoming.AuthInfos[target.AuthInfo] = user
+	incoming.Contexts[target.Cluster] = &api.Context{Cluster: target.Cluster, AuthInfo: target.AuthInfo}
+	return clientcmd.Write(*incoming)
+}
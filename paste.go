@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// pasteSettle is how long the pasted text has to stay unchanged and valid
+// before the paste prompt advances on its own. Terminals without bracketed
+// paste deliver a paste as many key events, so a kubeconfig may parse before
+// it is complete.
+const pasteSettle = 500 * time.Millisecond
+
+var (
+	validStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	invalidStyle = lipgloss.NewStyle().Faint(true)
+)
+
+var errPasteAborted = errors.New("aborted")
+
+// pasteSettledMsg is sent pasteSettle after an edit; it is stale if the
+// text has been edited again since.
+type pasteSettledMsg struct{ revision int }
+
+// pasteModel is a textarea that accepts a kubeconfig as soon as the pasted
+// text parses with at least one cluster and one user, or on ctrl+d.
+type pasteModel struct {
+	input    textarea.Model
+	revision int
+	status   string
+	valid    bool
+	done     bool
+	aborted  bool
+}
+
+// pasteKubeconfig prompts for a kubeconfig to be pasted and returns it. In
+// plain mode the kubeconfig is read from stdin up to EOF instead, so it can
+// also be piped in.
+func pasteKubeconfig() (string, error) {
+	if plainMode() {
+		if canPrompt() {
+			fmt.Println("Paste kubeconfig, then press ctrl+d on an empty line:")
+		}
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+
+	if err := applyTUITheme(); err != nil {
+		return "", err
+	}
+
+	input := textarea.New()
+	input.Placeholder = "apiVersion: v1 ..."
+	input.CharLimit = 99999
+	input.ShowLineNumbers = false
+	input.SetWidth(80)
+	input.SetHeight(10)
+	input.Focus()
+
+	m := &pasteModel{input: input, status: "waiting for input"}
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		return "", err
+	}
+	if m.aborted {
+		return "", errPasteAborted
+	}
+	return m.input.Value(), nil
+}
+
+func (m *pasteModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m *pasteModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.aborted = true
+			return m, tea.Quit
+		case "ctrl+d":
+			m.done = true
+			return m, tea.Quit
+		}
+	case pasteSettledMsg:
+		if msg.revision == m.revision && m.valid {
+			m.done = true
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() == before {
+		return m, cmd
+	}
+	m.revision++
+	m.valid, m.status = checkPastedKubeconfig(m.input.Value())
+	if !m.valid {
+		return m, cmd
+	}
+	revision := m.revision
+	settle := tea.Tick(pasteSettle, func(time.Time) tea.Msg { return pasteSettledMsg{revision} })
+	return m, tea.Batch(cmd, settle)
+}
+
+func (m *pasteModel) View() string {
+	if m.done || m.aborted {
+		return ""
+	}
+	status := invalidStyle.Render(m.status)
+	if m.valid {
+		status = validStyle.Render("looks valid ✓ " + m.status)
+	}
+	return titleStyle.Render("Paste kubeconfig") + "\n" +
+		m.input.View() + "\n" +
+		status + "\n" +
+		helpStyle.Render("continues automatically once complete • ctrl+d done • esc abort") + "\n"
+}
+
+// checkPastedKubeconfig reports whether text is a complete kubeconfig with
+// clusters and users, and a short status describing it.
+func checkPastedKubeconfig(text string) (bool, string) {
+	data, err := decodeInput([]byte(text), false)
+	if err != nil {
+		return false, err.Error()
+	}
+	if docs, total := kubeconfigDocuments(data); total > 1 {
+		if len(docs) == 0 {
+			return false, fmt.Sprintf("no kubeconfig among %d documents yet", total)
+		}
+		data = docs[0].data
+	}
+	cfg, err := clientcmd.Load(data)
+	switch {
+	case err != nil:
+		return false, "not a valid kubeconfig yet"
+	case len(cfg.Clusters) == 0:
+		return false, "no clusters yet"
+	case len(cfg.AuthInfos) == 0:
+		return false, "no users yet"
+	}
+	if err := kubeconfig.Validate(data); err != nil {
+		var errs kubeconfig.ValidationError
+		if !errors.As(err, &errs) {
+			return false, err.Error()
+		}
+		if len(errs) > 1 {
+			return false, fmt.Sprintf("%v (and %d more problems)", errs[0], len(errs)-1)
+		}
+		return false, errs[0].Error()
+	}
+	return true, fmt.Sprintf("(%d clusters, %d users, %d contexts)", len(cfg.Clusters), len(cfg.AuthInfos), len(cfg.Contexts))
+}
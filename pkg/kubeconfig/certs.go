@@ -0,0 +1,71 @@
+package kubeconfig
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseCertificates decodes all PEM encoded certificates in data. Blocks
+// that are not certificates or fail to parse are skipped.
+func ParseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// DescribeCertificate returns a one line description of a certificate with
+// its subject, issuer and expiry.
+func DescribeCertificate(cert *x509.Certificate) string {
+	return fmt.Sprintf("subject %q, issuer %q, expires %s (%s)",
+		cert.Subject.String(), cert.Issuer.String(),
+		cert.NotAfter.Format(time.RFC3339), DescribeExpiry(cert.NotAfter))
+}
+
+// DescribeExpiry describes how far t lies in the future or past.
+func DescribeExpiry(t time.Time) string {
+	d := time.Until(t)
+	if d < 0 {
+		return fmt.Sprintf("EXPIRED %s ago", FormatDays(-d))
+	}
+	return fmt.Sprintf("in %s", FormatDays(d))
+}
+
+// FormatDays formats a duration in days, or hours when less than a day.
+func FormatDays(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// CertSummary returns a short description of the first certificate in data
+// for use in change summaries, or an empty string if data holds none.
+func CertSummary(data []byte) string {
+	certs := ParseCertificates(data)
+	if len(certs) == 0 {
+		return ""
+	}
+	cert := certs[0]
+	var parts []string
+	if cert.Subject.CommonName != "" {
+		parts = append(parts, "CN="+cert.Subject.CommonName)
+	}
+	parts = append(parts, "expires "+cert.NotAfter.Format("2006-01-02"))
+	return " (" + strings.Join(parts, ", ") + ")"
+}
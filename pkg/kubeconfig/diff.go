@@ -0,0 +1,115 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Diff describes the differences between two kubeconfigs, in the same form
+// as the changes returned by Merge. Secrets are shortened.
+func Diff(old, new *api.Config) []string {
+	var changes []string
+	if old.CurrentContext != new.CurrentContext {
+		changes = append(changes, fmt.Sprintf("Updated current-context from %q to %q", old.CurrentContext, new.CurrentContext))
+	}
+
+	for _, name := range unionKeys(old.Clusters, new.Clusters) {
+		o, n := old.Clusters[name], new.Clusters[name]
+		switch {
+		case o == nil:
+			changes = append(changes, fmt.Sprintf("Added cluster %q with server %s", name, n.Server))
+		case n == nil:
+			changes = append(changes, fmt.Sprintf("Removed cluster %q", name))
+		default:
+			if o.Server != n.Server {
+				changes = append(changes, fmt.Sprintf("Updated cluster %q server from %s to %s", name, o.Server, n.Server))
+			}
+			if !bytes.Equal(o.CertificateAuthorityData, n.CertificateAuthorityData) {
+				changes = append(changes, fmt.Sprintf("Updated cluster %q CA data from %s to %s%s", name,
+					ShortenBytes(o.CertificateAuthorityData), ShortenBytes(n.CertificateAuthorityData),
+					CertSummary(n.CertificateAuthorityData)))
+			}
+			if o.CertificateAuthority != n.CertificateAuthority {
+				changes = append(changes, fmt.Sprintf("Updated cluster %q CA file from %q to %q", name, o.CertificateAuthority, n.CertificateAuthority))
+			}
+			if o.InsecureSkipTLSVerify != n.InsecureSkipTLSVerify {
+				changes = append(changes, fmt.Sprintf("Updated cluster %q insecure-skip-tls-verify from %t to %t", name, o.InsecureSkipTLSVerify, n.InsecureSkipTLSVerify))
+			}
+		}
+	}
+
+	for _, name := range unionKeys(old.AuthInfos, new.AuthInfos) {
+		o, n := old.AuthInfos[name], new.AuthInfos[name]
+		switch {
+		case o == nil:
+			changes = append(changes, fmt.Sprintf("Added user %q", name))
+		case n == nil:
+			changes = append(changes, fmt.Sprintf("Removed user %q", name))
+		default:
+			if o.Token != n.Token {
+				changes = append(changes, fmt.Sprintf("Updated user %q token from %s to %s", name, DescribeToken(o.Token), DescribeToken(n.Token)))
+			}
+			if !bytes.Equal(o.ClientCertificateData, n.ClientCertificateData) {
+				changes = append(changes, fmt.Sprintf("Updated user %q client cert from %s to %s%s", name,
+					ShortenBytes(o.ClientCertificateData), ShortenBytes(n.ClientCertificateData),
+					CertSummary(n.ClientCertificateData)))
+			}
+			if !bytes.Equal(o.ClientKeyData, n.ClientKeyData) {
+				changes = append(changes, fmt.Sprintf("Updated user %q client key from %s to %s", name,
+					ShortenBytes(o.ClientKeyData), ShortenBytes(n.ClientKeyData)))
+			}
+			if !reflect.DeepEqual(o.Exec, n.Exec) {
+				changes = append(changes, fmt.Sprintf("Updated user %q exec command from %s to %s", name, DescribeExec(o.Exec), DescribeExec(n.Exec)))
+			}
+			if !reflect.DeepEqual(o.AuthProvider, n.AuthProvider) {
+				changes = append(changes, fmt.Sprintf("Updated user %q auth provider from %s to %s", name,
+					DescribeAuthProvider(o.AuthProvider), DescribeAuthProvider(n.AuthProvider)))
+			}
+			if oi, ni := GetImpersonation(o), GetImpersonation(n); !oi.Equal(ni) {
+				changes = append(changes, fmt.Sprintf("Updated user %q impersonation from %s to %s", name, oi, ni))
+			}
+		}
+	}
+
+	for _, name := range unionKeys(old.Contexts, new.Contexts) {
+		o, n := old.Contexts[name], new.Contexts[name]
+		switch {
+		case o == nil:
+			changes = append(changes, fmt.Sprintf("Added context %q with cluster %q and user %q", name, n.Cluster, n.AuthInfo))
+		case n == nil:
+			changes = append(changes, fmt.Sprintf("Removed context %q", name))
+		default:
+			if o.Cluster != n.Cluster {
+				changes = append(changes, fmt.Sprintf("Updated context %q cluster from %q to %q", name, o.Cluster, n.Cluster))
+			}
+			if o.AuthInfo != n.AuthInfo {
+				changes = append(changes, fmt.Sprintf("Updated context %q user from %q to %q", name, o.AuthInfo, n.AuthInfo))
+			}
+			if o.Namespace != n.Namespace {
+				changes = append(changes, fmt.Sprintf("Updated context %q namespace from %q to %q", name, o.Namespace, n.Namespace))
+			}
+		}
+	}
+	return changes
+}
+
+// unionKeys returns the sorted names present in either map.
+func unionKeys[T any](a, b map[string]T) []string {
+	seen := map[string]bool{}
+	for name := range a {
+		seen[name] = true
+	}
+	for name := range b {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,48 @@
+package kubeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ExtensionPrefix is the prefix of the names of all extensions written by
+// this package.
+const ExtensionPrefix = "kubeconfig-updater/"
+
+// GetExtension decodes the named extension from exts into v. It reports
+// false if the extension is not present.
+func GetExtension(exts map[string]runtime.Object, name string, v interface{}) (bool, error) {
+	obj, exists := exts[name]
+	if !exists || obj == nil {
+		return false, nil
+	}
+	var raw []byte
+	if unknown, ok := obj.(*runtime.Unknown); ok {
+		raw = unknown.Raw
+	} else {
+		var err error
+		if raw, err = json.Marshal(obj); err != nil {
+			return true, fmt.Errorf("encoding extension %s: %w", name, err)
+		}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("decoding extension %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// SetExtension stores v as JSON in the named extension, creating the map
+// if necessary.
+func SetExtension(exts *map[string]runtime.Object, name string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding extension %s: %w", name, err)
+	}
+	if *exts == nil {
+		*exts = map[string]runtime.Object{}
+	}
+	(*exts)[name] = &runtime.Unknown{Raw: raw, ContentType: runtime.ContentTypeJSON}
+	return nil
+}
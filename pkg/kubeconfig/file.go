@@ -0,0 +1,190 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Load reads and parses the kubeconfig at path, decrypting it first if it
+// is encrypted with sops. The raw file content is returned alongside the
+// parsed config so it can be backed up on save.
+func Load(path string) ([]byte, *api.Config, error) {
+	Debugf("reading kubeconfig %s", path)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading kubeconfig file %s: %w", path, err)
+	}
+	plain := data
+	if meta := ReadSopsMetadata(data); meta != nil {
+		Debugf("%s is encrypted with sops, decrypting", path)
+		plain, err = DecryptSops(path)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	cfg, err := clientcmd.Load(plain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	SetLocationOfOrigin(cfg, path)
+	Debugf("loaded %d contexts, %d clusters and %d users, current context %q",
+		len(cfg.Contexts), len(cfg.Clusters), len(cfg.AuthInfos), cfg.CurrentContext)
+	return data, cfg, nil
+}
+
+// SetLocationOfOrigin records path as the origin of all entries in cfg, so
+// relative certificate and key file references resolve against the
+// directory of the kubeconfig file.
+func SetLocationOfOrigin(cfg *api.Config, path string) {
+	for _, cluster := range cfg.Clusters {
+		cluster.LocationOfOrigin = path
+	}
+	for _, user := range cfg.AuthInfos {
+		user.LocationOfOrigin = path
+	}
+	for _, ctx := range cfg.Contexts {
+		ctx.LocationOfOrigin = path
+	}
+}
+
+// Encode serializes cfg for writing to path in the given format, or in the
+// format of origData, the previous content of path, if format is empty. If
+// origData was encrypted with sops, the result is encrypted again with the
+// same keys.
+func Encode(path string, origData []byte, cfg *api.Config, format string) ([]byte, error) {
+	if format == "" {
+		format = DetectFormat(origData)
+	}
+	outData, err := Marshal(cfg, format)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling updated config: %w", err)
+	}
+	if meta := ReadSopsMetadata(origData); meta != nil {
+		Debugf("encrypting %s again with sops", path)
+		return EncryptSops(path, meta, outData)
+	}
+	return outData, nil
+}
+
+// Save backs up origData into backupDir, or next to path if backupDir is
+// empty, and replaces path with outData. It returns the path of the backup.
+func Save(path string, origData, outData []byte, backupDir string) (string, error) {
+	backupPath, err := Backup(path, origData, backupDir)
+	if err != nil {
+		return "", fmt.Errorf("creating backup: %w", err)
+	}
+	Debugf("writing kubeconfig %s", path)
+	if err := ioutil.WriteFile(path, outData, filePerm(path)); err != nil {
+		return backupPath, fmt.Errorf("writing updated config: %w", err)
+	}
+	return backupPath, nil
+}
+
+// Backup saves data as timestamped backup of the kubeconfig at path in dir,
+// or next to path if dir is empty, and returns the backup's path.
+func Backup(path string, data []byte, dir string) (string, error) {
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	backupPath := filepath.Join(dir, fmt.Sprintf("%s.backup.%s", filepath.Base(path), time.Now().Format(backupStampLayout())))
+	Debugf("writing backup %s", backupPath)
+	if err := ioutil.WriteFile(backupPath, data, filePerm(path)); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// windowsBackupStampLayout is RFC 3339 without the colons, which are not
+// allowed in file names on Windows.
+const windowsBackupStampLayout = "2006-01-02T150405Z0700"
+
+// backupStampLayout returns the time layout of backup file name suffixes.
+func backupStampLayout() string {
+	if runtime.GOOS == "windows" {
+		return windowsBackupStampLayout
+	}
+	return time.RFC3339
+}
+
+// parseBackupStamp parses the timestamp suffix of a backup file name in
+// either layout, so backups can be listed whichever system wrote them.
+func parseBackupStamp(stamp string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, windowsBackupStampLayout} {
+		if t, err := time.Parse(layout, stamp); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// filePerm returns the permissions to write the kubeconfig at path and its
+// backups with: those of the existing file, so a kubeconfig restricted with
+// chmod 600 keeps its backups restricted too, or 0644 for a new one. The
+// owner may always write, as on Windows, where the permission bits only map
+// to the read-only attribute, read-only backups could not be pruned.
+func filePerm(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm() | 0o200
+	}
+	return 0o644
+}
+
+// ListBackups returns the backups of the kubeconfig at path found in dir,
+// or next to path if dir is empty, oldest first.
+func ListBackups(path, dir string) ([]string, error) {
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	prefix := filepath.Base(path) + ".backup."
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	stamps := map[string]time.Time{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		stamp, ok := parseBackupStamp(strings.TrimPrefix(entry.Name(), prefix))
+		if !ok {
+			continue
+		}
+		backup := filepath.Join(dir, entry.Name())
+		backups = append(backups, backup)
+		stamps[backup] = stamp
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return stamps[backups[i]].Before(stamps[backups[j]])
+	})
+	return backups, nil
+}
+
+// PruneBackups removes all but the newest keep backups of the kubeconfig at
+// path found in dir, or next to path if dir is empty.
+func PruneBackups(path, dir string, keep int) error {
+	backups, err := ListBackups(path, dir)
+	if err != nil {
+		return err
+	}
+	for len(backups) > keep {
+		Debugf("removing old backup %s", backups[0])
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestEncodeLoadRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatYAML, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.CurrentContext = "prod"
+			if err := SetTags(cfg.Contexts["prod"], map[string]string{"env": "prod"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := MergeUser(cfg, "admin", &api.AuthInfo{Token: "new-token"}, nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := SetExtension(&cfg.Extensions, ExtensionPrefix+"test", map[string]string{"key": "value"}); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "config")
+			data, err := Encode(path, nil, cfg, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectFormat(data); got != format {
+				t.Errorf("encoded as %s, want %s", got, format)
+			}
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, loaded, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if loaded.CurrentContext != "prod" || loaded.AuthInfos["admin"].Token != "new-token" {
+				t.Errorf("loaded current context %q and token %q", loaded.CurrentContext, loaded.AuthInfos["admin"].Token)
+			}
+			if got := loaded.Contexts["prod"].LocationOfOrigin; got != path {
+				t.Errorf("location of origin = %s, want %s", got, path)
+			}
+			tags, err := GetTags(loaded.Contexts["prod"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tags, map[string]string{"env": "prod"}) {
+				t.Errorf("tags = %v", tags)
+			}
+			var synced, wantSynced map[string]string
+			if _, err := GetExtension(cfg.AuthInfos["admin"].Extensions, SyncExtension, &wantSynced); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := GetExtension(loaded.AuthInfos["admin"].Extensions, SyncExtension, &synced); !ok || err != nil {
+				t.Fatalf("sync extension lost: %v", err)
+			}
+			if !reflect.DeepEqual(synced, wantSynced) {
+				t.Errorf("sync extension = %v, want %v", synced, wantSynced)
+			}
+			var global map[string]string
+			if ok, err := GetExtension(loaded.Extensions, ExtensionPrefix+"test", &global); !ok || err != nil || global["key"] != "value" {
+				t.Errorf("config extension = %v (%v)", global, err)
+			}
+		})
+	}
+}
+
+func TestEncodeKeepsFormat(t *testing.T) {
+	data, err := Encode("config", []byte(`{"apiVersion": "v1"}`), testConfig(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectFormat(data); got != FormatJSON {
+		t.Errorf("encoded as %s, want the format of the original, json", got)
+	}
+}
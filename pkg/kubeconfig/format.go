@@ -0,0 +1,54 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+// Serialization formats of a kubeconfig. kubectl reads both.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// DetectFormat returns FormatJSON if data is a JSON document and FormatYAML
+// otherwise.
+func DetectFormat(data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Marshal serializes cfg in the given format.
+func Marshal(cfg *api.Config, format string) ([]byte, error) {
+	data, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertFormat(data, format)
+}
+
+// ConvertFormat converts the YAML kubeconfig data into the given format.
+func ConvertFormat(data []byte, format string) ([]byte, error) {
+	switch format {
+	case FormatYAML, "":
+		return data, nil
+	case FormatJSON:
+		compact, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("converting to JSON: %w", err)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, compact, "", "  "); err != nil {
+			return nil, fmt.Errorf("converting to JSON: %w", err)
+		}
+		return append(out.Bytes(), '\n'), nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected %s or %s", format, FormatYAML, FormatJSON)
+}
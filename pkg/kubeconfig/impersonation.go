@@ -0,0 +1,109 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Impersonation holds the as, as-uid, as-groups and as-user-extra fields of
+// a user, naming whom requests are made on behalf of.
+type Impersonation struct {
+	User   string              `json:"as,omitempty"`
+	UID    string              `json:"as-uid,omitempty"`
+	Groups []string            `json:"as-groups,omitempty"`
+	Extra  map[string][]string `json:"as-user-extra,omitempty"`
+}
+
+// GetImpersonation returns the impersonation fields of user.
+func GetImpersonation(user *api.AuthInfo) Impersonation {
+	return Impersonation{
+		User:   user.Impersonate,
+		UID:    user.ImpersonateUID,
+		Groups: user.ImpersonateGroups,
+		Extra:  user.ImpersonateUserExtra,
+	}
+}
+
+// SetImpersonation replaces the impersonation fields of user.
+func SetImpersonation(user *api.AuthInfo, imp Impersonation) {
+	user.Impersonate = imp.User
+	user.ImpersonateUID = imp.UID
+	user.ImpersonateGroups = imp.Groups
+	user.ImpersonateUserExtra = imp.Extra
+}
+
+// IsZero reports whether no impersonation field is set.
+func (i Impersonation) IsZero() bool {
+	return i.User == "" && i.UID == "" && len(i.Groups) == 0 && len(i.Extra) == 0
+}
+
+// Equal reports whether both impersonate the same identity.
+func (i Impersonation) Equal(o Impersonation) bool {
+	if i.IsZero() || o.IsZero() {
+		return i.IsZero() == o.IsZero()
+	}
+	return i.User == o.User && i.UID == o.UID && reflect.DeepEqual(i.Groups, o.Groups) &&
+		reflect.DeepEqual(i.Extra, o.Extra)
+}
+
+// FormatExtra formats user extra values as comma separated key=value pairs,
+// repeating the key for multiple values.
+func FormatExtra(extra map[string][]string) string {
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		for _, value := range extra[key] {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseExtra parses user extra values formatted by FormatExtra.
+func ParseExtra(s string) (map[string][]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	extra := map[string][]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid user extra %q, expected key=value", pair)
+		}
+		extra[key] = append(extra[key], value)
+	}
+	return extra, nil
+}
+
+// String describes the impersonated identity, or returns "<none>".
+func (i Impersonation) String() string {
+	if i.IsZero() {
+		return "<none>"
+	}
+	s := i.User
+	if s == "" {
+		s = "<same user>"
+	}
+	var details []string
+	if i.UID != "" {
+		details = append(details, "uid "+i.UID)
+	}
+	if len(i.Groups) > 0 {
+		details = append(details, "groups "+strings.Join(i.Groups, ","))
+	}
+	if len(i.Extra) > 0 {
+		details = append(details, "extra "+FormatExtra(i.Extra))
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWTClaims holds the registered JWT claims the updater looks at.
+type JWTClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Expiry returns the expiry time of the token and whether it has one.
+func (c *JWTClaims) Expiry() (time.Time, bool) {
+	if c.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(c.ExpiresAt, 0), true
+}
+
+// DecodeJWTClaims decodes the claims of a JWT without verifying its
+// signature. It fails if token is not a JWT.
+func DecodeJWTClaims(token string) (*JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("decoding JWT payload: %w", err)
+	}
+	var claims JWTClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("parsing JWT claims: %w", err)
+	}
+	return &claims, nil
+}
+
+// DescribeToken returns the shortened token followed by its issuer, subject
+// and expiry if the token is a JWT.
+func DescribeToken(token string) string {
+	if token == "" {
+		return "<empty>"
+	}
+	claims, err := DecodeJWTClaims(token)
+	if err != nil {
+		return Shorten(token)
+	}
+	return fmt.Sprintf("%s (%s)", Shorten(token), claims.String())
+}
+
+// String formats the claims as a comma separated list.
+func (c *JWTClaims) String() string {
+	var parts []string
+	if c.Issuer != "" {
+		parts = append(parts, "iss "+c.Issuer)
+	}
+	if c.Subject != "" {
+		parts = append(parts, "sub "+c.Subject)
+	}
+	if exp, ok := c.Expiry(); ok {
+		parts = append(parts, fmt.Sprintf("expires %s, %s", exp.Format(time.RFC3339), DescribeExpiry(exp)))
+	} else {
+		parts = append(parts, "no expiry")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,56 @@
+// Package kubeconfig loads, merges, compares and saves kubeconfig files. It
+// holds the merge engine of kubeconfig-updater, so other tools can update
+// kubeconfigs the same way the command does.
+//
+// All functions that modify a config return a human readable description of
+// every change made, with secrets shortened, suitable for printing as a
+// summary.
+package kubeconfig
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Debugf is called with a description of every decision the merge engine
+// makes and every file it reads or writes. It does nothing by default.
+var Debugf = func(format string, args ...interface{}) {}
+
+// Shorten returns the first and last five characters of s, so secrets can
+// be told apart in summaries without revealing them.
+func Shorten(s string) string {
+	if len(s) <= 15 {
+		return s
+	}
+	return fmt.Sprintf("%s...%s", s[:5], s[len(s)-5:])
+}
+
+// ShortenBytes base64 encodes the byte slice before shortening.
+func ShortenBytes(data []byte) string {
+	if len(data) == 0 {
+		return "<empty>"
+	}
+	return Shorten(base64.StdEncoding.EncodeToString(data))
+}
+
+// run runs an external command with input on its standard input and returns
+// its standard output, including its standard error in the error on failure.
+func run(input []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running %s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("running %s: %w", name, err)
+	}
+	return out, nil
+}
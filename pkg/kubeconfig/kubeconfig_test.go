@@ -0,0 +1,58 @@
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestShortenBytes(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{nil, "<empty>"},
+		{[]byte("short"), "c2hvcnQ="},
+		{[]byte("a much longer secret value"), "YSBtd...sdWU="},
+	}
+	for _, tt := range tests {
+		if got := ShortenBytes(tt.data); got != tt.want {
+			t.Errorf("ShortenBytes(%q) = %s, want %s", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeToken(t *testing.T) {
+	jwt := func(claims string) string {
+		encode := base64.RawURLEncoding.EncodeToString
+		return encode([]byte(`{"alg":"none"}`)) + "." + encode([]byte(claims)) + ".sig"
+	}
+	tests := []struct {
+		name  string
+		token string
+		want  []string
+	}{
+		{"empty", "", []string{"<empty>"}},
+		{"short", "abc", []string{"abc"}},
+		{"opaque", "0123456789abcdefghij", []string{"01234...fghij"}},
+		{
+			name:  "JWT",
+			token: jwt(`{"iss":"https://issuer","sub":"system:serviceaccount:ci:deploy","exp":1672628645}`),
+			want:  []string{"eyJhb...", "(iss https://issuer, sub system:serviceaccount:ci:deploy, expires 2023-01-0", ", EXPIRED "},
+		},
+		{"JWT without expiry", jwt(`{"sub":"me"}`), []string{"(sub me, no expiry)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DescribeToken(tt.token)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("DescribeToken() = %s, want it to contain %s", got, want)
+				}
+			}
+			if len(tt.token) > 15 && strings.Contains(got, tt.token) {
+				t.Errorf("DescribeToken() = %s reveals the token", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// MergeCluster updates the named cluster in cfg with the CA data of incoming,
+// and with its server URL if updateServer is set. Missing clusters are added.
+// Differing fields are only taken if resolve agrees. It returns a
+// description of every change made.
+func MergeCluster(cfg *api.Config, name string, incoming *api.Cluster, updateServer bool, resolve Resolver) ([]string, error) {
+	existing, exists := cfg.Clusters[name]
+	if !exists {
+		Debugf("cluster %q not found in kubeconfig, adding it", name)
+		existing = api.NewCluster()
+	}
+	m, err := newFieldMerge("cluster", name, existing.Extensions, resolve)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		m.resolve = nil
+	}
+
+	Debugf("merging cluster %q CA data (server too: %t)", name, updateServer)
+	var changes []string
+	if updateServer || !exists {
+		take, err := m.take("server", existing.Server, incoming.Server, existing.Server, incoming.Server, existing.Server == "")
+		if err != nil {
+			return nil, err
+		}
+		if take && exists {
+			changes = append(changes, fmt.Sprintf("Updated cluster %q server from %s to %s",
+				name, existing.Server, incoming.Server))
+		}
+		if take {
+			existing.Server = incoming.Server
+		}
+	}
+	take, err := m.take("certificate-authority-data", existing.CertificateAuthorityData, incoming.CertificateAuthorityData,
+		ShortenBytes(existing.CertificateAuthorityData), ShortenBytes(incoming.CertificateAuthorityData),
+		len(existing.CertificateAuthorityData) == 0)
+	if err != nil {
+		return nil, err
+	}
+	if take && exists {
+		changes = append(changes, fmt.Sprintf("Updated cluster %q CA data from %s to %s%s",
+			name, ShortenBytes(existing.CertificateAuthorityData), ShortenBytes(incoming.CertificateAuthorityData),
+			CertSummary(incoming.CertificateAuthorityData)))
+	}
+	if take {
+		existing.CertificateAuthorityData = incoming.CertificateAuthorityData
+	}
+
+	if !exists {
+		existing = incoming
+		cfg.Clusters[name] = incoming
+		changes = append(changes, fmt.Sprintf("Added cluster %q with server %s and CA data %s%s",
+			name, incoming.Server, ShortenBytes(incoming.CertificateAuthorityData),
+			CertSummary(incoming.CertificateAuthorityData)))
+	}
+	if err := m.record(&existing.Extensions); err != nil {
+		return nil, err
+	}
+	return append(changes, m.changes...), nil
+}
+
+// MergeUser updates the credentials of the named user in cfg from incoming,
+// or adds the user if missing. Differing fields are only taken if resolve
+// agrees. It returns a description of every change made.
+func MergeUser(cfg *api.Config, name string, incoming *api.AuthInfo, resolve Resolver) ([]string, error) {
+	existing, exists := cfg.AuthInfos[name]
+	if !exists {
+		Debugf("user %q not found in kubeconfig, adding it", name)
+		existing = api.NewAuthInfo()
+	}
+	m, err := newFieldMerge("user", name, existing.Extensions, resolve)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		m.resolve = nil
+	}
+
+	Debugf("merging credentials of user %q", name)
+	var changes []string
+	take, err := m.take("token", existing.Token, incoming.Token,
+		DescribeToken(existing.Token), DescribeToken(incoming.Token), existing.Token == "")
+	if err != nil {
+		return nil, err
+	}
+	if take {
+		changes = append(changes, fmt.Sprintf("Updated user %q token from %s to %s",
+			name, DescribeToken(existing.Token), DescribeToken(incoming.Token)))
+		existing.Token = incoming.Token
+	}
+	take, err = m.take("client-certificate-data", existing.ClientCertificateData, incoming.ClientCertificateData,
+		ShortenBytes(existing.ClientCertificateData), ShortenBytes(incoming.ClientCertificateData),
+		len(existing.ClientCertificateData) == 0)
+	if err != nil {
+		return nil, err
+	}
+	if take {
+		changes = append(changes, fmt.Sprintf("Updated user %q client cert from %s to %s%s",
+			name, ShortenBytes(existing.ClientCertificateData), ShortenBytes(incoming.ClientCertificateData),
+			CertSummary(incoming.ClientCertificateData)))
+		existing.ClientCertificateData = incoming.ClientCertificateData
+	}
+	take, err = m.take("client-key-data", existing.ClientKeyData, incoming.ClientKeyData,
+		ShortenBytes(existing.ClientKeyData), ShortenBytes(incoming.ClientKeyData), len(existing.ClientKeyData) == 0)
+	if err != nil {
+		return nil, err
+	}
+	if take {
+		changes = append(changes, fmt.Sprintf("Updated user %q client key from %s to %s",
+			name, ShortenBytes(existing.ClientKeyData), ShortenBytes(incoming.ClientKeyData)))
+		existing.ClientKeyData = incoming.ClientKeyData
+	}
+	take, err = m.take("exec", existing.Exec, incoming.Exec,
+		DescribeExec(existing.Exec), DescribeExec(incoming.Exec), existing.Exec == nil)
+	if err != nil {
+		return nil, err
+	}
+	if take {
+		changes = append(changes, fmt.Sprintf("Updated user %q exec command from %s to %s",
+			name, DescribeExec(existing.Exec), DescribeExec(incoming.Exec)))
+		existing.Exec = incoming.Exec
+	}
+	take, err = m.take("auth-provider", existing.AuthProvider, incoming.AuthProvider,
+		DescribeAuthProvider(existing.AuthProvider), DescribeAuthProvider(incoming.AuthProvider), existing.AuthProvider == nil)
+	if err != nil {
+		return nil, err
+	}
+	if take {
+		changes = append(changes, fmt.Sprintf("Updated user %q auth provider from %s to %s",
+			name, DescribeAuthProvider(existing.AuthProvider), DescribeAuthProvider(incoming.AuthProvider)))
+		existing.AuthProvider = incoming.AuthProvider
+	}
+	// Impersonation is usually configured locally, so it is only taken if
+	// the incoming user sets it
+	if imp := GetImpersonation(incoming); !imp.IsZero() {
+		local := GetImpersonation(existing)
+		take, err = m.take("impersonation", local, imp, local.String(), imp.String(), local.IsZero())
+		if err != nil {
+			return nil, err
+		}
+		if take {
+			changes = append(changes, fmt.Sprintf("Updated user %q impersonation from %s to %s", name, local, imp))
+			SetImpersonation(existing, imp)
+		}
+	}
+
+	if !exists {
+		existing = incoming
+		cfg.AuthInfos[name] = incoming
+		if incoming.Exec != nil {
+			changes = []string{fmt.Sprintf("Added user %q with exec command %s", name, DescribeExec(incoming.Exec))}
+		} else {
+			changes = []string{fmt.Sprintf("Added user %q with token %s, client cert %s%s, and client key %s",
+				name, DescribeToken(incoming.Token), ShortenBytes(incoming.ClientCertificateData),
+				CertSummary(incoming.ClientCertificateData), ShortenBytes(incoming.ClientKeyData))}
+		}
+	}
+	if err := m.record(&existing.Extensions); err != nil {
+		return nil, err
+	}
+	return append(changes, m.changes...), nil
+}
+
+// Merge merges every context of src, together with its cluster and
+// user, into dst. Entries are matched by name; existing clusters get their
+// server and CA updated, existing users their credentials, as far as
+// resolve agrees.
+func Merge(dst, src *api.Config, resolve Resolver) ([]string, error) {
+	var names []string
+	for name := range src.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []string
+	for _, name := range names {
+		ctx := src.Contexts[name]
+		cluster, exists := src.Clusters[ctx.Cluster]
+		if !exists {
+			return nil, fmt.Errorf("context %s references missing cluster %s", name, ctx.Cluster)
+		}
+		user, exists := src.AuthInfos[ctx.AuthInfo]
+		if !exists {
+			return nil, fmt.Errorf("context %s references missing user %s", name, ctx.AuthInfo)
+		}
+		Debugf("merging context %q with cluster %q and user %q", name, ctx.Cluster, ctx.AuthInfo)
+		clusterChanges, err := MergeCluster(dst, ctx.Cluster, cluster, true, resolve)
+		if err != nil {
+			return nil, err
+		}
+		userChanges, err := MergeUser(dst, ctx.AuthInfo, user, resolve)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, clusterChanges...)
+		changes = append(changes, userChanges...)
+		changes = append(changes, MergeContext(dst, name, ctx)...)
+	}
+	return changes, nil
+}
+
+// MergeContext points the named context in cfg at the cluster, user and
+// namespace of incoming, adding the context if missing.
+func MergeContext(cfg *api.Config, name string, incoming *api.Context) []string {
+	existing, exists := cfg.Contexts[name]
+	if !exists {
+		Debugf("context %q not found in kubeconfig, adding it", name)
+		cfg.Contexts[name] = incoming
+		return []string{fmt.Sprintf("Added context %q with cluster %q and user %q", name, incoming.Cluster, incoming.AuthInfo)}
+	}
+
+	var changes []string
+	if existing.Cluster != incoming.Cluster {
+		changes = append(changes, fmt.Sprintf("Updated context %q cluster from %q to %q", name, existing.Cluster, incoming.Cluster))
+		existing.Cluster = incoming.Cluster
+	}
+	if existing.AuthInfo != incoming.AuthInfo {
+		changes = append(changes, fmt.Sprintf("Updated context %q user from %q to %q", name, existing.AuthInfo, incoming.AuthInfo))
+		existing.AuthInfo = incoming.AuthInfo
+	}
+	if incoming.Namespace != "" {
+		if change := SetNamespace(name, existing, incoming.Namespace); change != "" {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// DescribeExec formats an exec credential plugin invocation.
+func DescribeExec(exec *api.ExecConfig) string {
+	if exec == nil {
+		return "<none>"
+	}
+	return strings.TrimSpace(exec.Command + " " + strings.Join(exec.Args, " "))
+}
+
+// DescribeAuthProvider names an auth provider without revealing its config.
+func DescribeAuthProvider(provider *api.AuthProviderConfig) string {
+	if provider == nil {
+		return "<none>"
+	}
+	return provider.Name
+}
+
+// SetNamespace sets the namespace of ctx and returns a change description,
+// or an empty string if the namespace was already set to that value.
+func SetNamespace(contextName string, ctx *api.Context, namespace string) string {
+	if ctx.Namespace == namespace {
+		return ""
+	}
+	old := ctx.Namespace
+	ctx.Namespace = namespace
+	switch {
+	case old == "":
+		return fmt.Sprintf("Set context %q namespace to %s", contextName, namespace)
+	case namespace == "":
+		return fmt.Sprintf("Cleared context %q namespace (was %s)", contextName, old)
+	default:
+		return fmt.Sprintf("Updated context %q namespace from %s to %s", contextName, old, namespace)
+	}
+}
@@ -0,0 +1,221 @@
+package kubeconfig
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func testConfig() *api.Config {
+	cfg := api.NewConfig()
+	cfg.Clusters["prod"] = &api.Cluster{Server: "https://prod:6443", CertificateAuthorityData: []byte("old-ca")}
+	cfg.AuthInfos["admin"] = &api.AuthInfo{Token: "old-token"}
+	cfg.Contexts["prod"] = &api.Context{Cluster: "prod", AuthInfo: "admin"}
+	return cfg
+}
+
+func TestMergeCluster(t *testing.T) {
+	tests := []struct {
+		name         string
+		cluster      string
+		incoming     *api.Cluster
+		updateServer bool
+		wantServer   string
+		wantCA       string
+		wantChanges  []string
+	}{
+		{
+			name:         "add",
+			cluster:      "staging",
+			incoming:     &api.Cluster{Server: "https://staging:6443", CertificateAuthorityData: []byte("ca")},
+			updateServer: false,
+			wantServer:   "https://staging:6443",
+			wantCA:       "ca",
+			wantChanges:  []string{`Added cluster "staging" with server https://staging:6443`},
+		},
+		{
+			name:         "update CA, keep server",
+			cluster:      "prod",
+			incoming:     &api.Cluster{Server: "https://new:6443", CertificateAuthorityData: []byte("new-ca")},
+			updateServer: false,
+			wantServer:   "https://prod:6443",
+			wantCA:       "new-ca",
+			wantChanges:  []string{`Updated cluster "prod" CA data from`},
+		},
+		{
+			name:         "update server and CA",
+			cluster:      "prod",
+			incoming:     &api.Cluster{Server: "https://new:6443", CertificateAuthorityData: []byte("new-ca")},
+			updateServer: true,
+			wantServer:   "https://new:6443",
+			wantCA:       "new-ca",
+			wantChanges: []string{
+				`Updated cluster "prod" server from https://prod:6443 to https://new:6443`,
+				`Updated cluster "prod" CA data from`,
+			},
+		},
+		{
+			name:         "unchanged",
+			cluster:      "prod",
+			incoming:     &api.Cluster{Server: "https://prod:6443", CertificateAuthorityData: []byte("old-ca")},
+			updateServer: true,
+			wantServer:   "https://prod:6443",
+			wantCA:       "old-ca",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			changes, err := MergeCluster(cfg, tt.cluster, tt.incoming, tt.updateServer, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := cfg.Clusters[tt.cluster]
+			if got.Server != tt.wantServer {
+				t.Errorf("server = %s, want %s", got.Server, tt.wantServer)
+			}
+			if string(got.CertificateAuthorityData) != tt.wantCA {
+				t.Errorf("CA data = %s, want %s", got.CertificateAuthorityData, tt.wantCA)
+			}
+			checkChanges(t, changes, tt.wantChanges)
+			if _, ok := got.Extensions[SyncExtension]; !ok {
+				t.Errorf("sync extension not recorded")
+			}
+		})
+	}
+}
+
+func TestMergeUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		user        string
+		incoming    *api.AuthInfo
+		wantToken   string
+		wantChanges []string
+	}{
+		{
+			name:        "add",
+			user:        "dev",
+			incoming:    &api.AuthInfo{Token: "dev-token"},
+			wantToken:   "dev-token",
+			wantChanges: []string{`Added user "dev" with token dev-token`},
+		},
+		{
+			name:        "add exec",
+			user:        "dev",
+			incoming:    &api.AuthInfo{Exec: &api.ExecConfig{Command: "kubelogin", Args: []string{"get-token"}}},
+			wantChanges: []string{`Added user "dev" with exec command kubelogin get-token`},
+		},
+		{
+			name:        "update token",
+			user:        "admin",
+			incoming:    &api.AuthInfo{Token: "new-token"},
+			wantToken:   "new-token",
+			wantChanges: []string{`Updated user "admin" token from old-token to new-token`},
+		},
+		{
+			name:      "unchanged",
+			user:      "admin",
+			incoming:  &api.AuthInfo{Token: "old-token"},
+			wantToken: "old-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			changes, err := MergeUser(cfg, tt.user, tt.incoming, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.AuthInfos[tt.user].Token; got != tt.wantToken {
+				t.Errorf("token = %s, want %s", got, tt.wantToken)
+			}
+			checkChanges(t, changes, tt.wantChanges)
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     *api.Config
+		wantErr string
+		want    []string
+	}{
+		{
+			name: "add and update",
+			src: &api.Config{
+				Clusters: map[string]*api.Cluster{
+					"prod":    {Server: "https://prod:6443", CertificateAuthorityData: []byte("old-ca")},
+					"staging": {Server: "https://staging:6443"},
+				},
+				AuthInfos: map[string]*api.AuthInfo{
+					"admin": {Token: "new-token"},
+					"dev":   {Token: "dev-token"},
+				},
+				Contexts: map[string]*api.Context{
+					"prod":    {Cluster: "prod", AuthInfo: "admin"},
+					"staging": {Cluster: "staging", AuthInfo: "dev", Namespace: "apps"},
+				},
+			},
+			want: []string{
+				`Updated user "admin" token from old-token to new-token`,
+				`Added cluster "staging"`,
+				`Added user "dev"`,
+				`Added context "staging" with cluster "staging" and user "dev"`,
+			},
+		},
+		{
+			name: "missing cluster",
+			src: &api.Config{
+				AuthInfos: map[string]*api.AuthInfo{"admin": {Token: "t"}},
+				Contexts:  map[string]*api.Context{"prod": {Cluster: "gone", AuthInfo: "admin"}},
+			},
+			wantErr: "context prod references missing cluster gone",
+		},
+		{
+			name: "missing user",
+			src: &api.Config{
+				Clusters: map[string]*api.Cluster{"prod": {Server: "https://prod:6443"}},
+				Contexts: map[string]*api.Context{"prod": {Cluster: "prod", AuthInfo: "gone"}},
+			},
+			wantErr: "context prod references missing user gone",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := testConfig()
+			changes, err := Merge(dst, tt.src, nil)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkChanges(t, changes, tt.want)
+			for name := range tt.src.Contexts {
+				if _, ok := dst.Contexts[name]; !ok {
+					t.Errorf("context %s missing after merge", name)
+				}
+			}
+		})
+	}
+}
+
+// checkChanges checks that changes has one entry starting with each of
+// prefixes, in order.
+func checkChanges(t *testing.T, changes, prefixes []string) {
+	t.Helper()
+	if len(changes) != len(prefixes) {
+		t.Fatalf("got %d changes %q, want %d", len(changes), changes, len(prefixes))
+	}
+	for i, prefix := range prefixes {
+		if !strings.HasPrefix(changes[i], prefix) {
+			t.Errorf("change %d = %q, want prefix %q", i, changes[i], prefix)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretFieldsRegex matches the kubeconfig keys holding credentials. It is
+// passed to sops as --encrypted-regex when only secrets are to be encrypted.
+const SecretFieldsRegex = "^(token|client-key-data|client-certificate-data|password|id-token|refresh-token|client-secret)$"
+
+// SopsMetadata is the subset of the sops metadata of an encrypted file that
+// is needed to encrypt it again with the same keys.
+type SopsMetadata struct {
+	Age            []SopsAgeKey `yaml:"age"`
+	PGP            []SopsPGPKey `yaml:"pgp"`
+	EncryptedRegex string       `yaml:"encrypted_regex"`
+}
+
+// SopsAgeKey is an age recipient in the sops metadata.
+type SopsAgeKey struct {
+	Recipient string `yaml:"recipient"`
+}
+
+// SopsPGPKey is a PGP key in the sops metadata.
+type SopsPGPKey struct {
+	Fingerprint string `yaml:"fp"`
+}
+
+// ReadSopsMetadata returns the sops metadata of data, or nil if data is not
+// a sops encrypted document.
+func ReadSopsMetadata(data []byte) *SopsMetadata {
+	var doc struct {
+		Sops *SopsMetadata `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	return doc.Sops
+}
+
+// DecryptSops returns the decrypted content of the sops encrypted file at
+// path.
+func DecryptSops(path string) ([]byte, error) {
+	out, err := run(nil, "sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", path)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// EncryptSops encrypts plain with the keys of meta. Without any keys, sops
+// picks them from the creation rules in .sops.yaml matching path.
+func EncryptSops(path string, meta *SopsMetadata, plain []byte) ([]byte, error) {
+	args := []string{"--encrypt", "--input-type", "yaml", "--output-type", "yaml", "--filename-override", path}
+	var recipients, fingerprints []string
+	for _, age := range meta.Age {
+		recipients = append(recipients, age.Recipient)
+	}
+	for _, pgp := range meta.PGP {
+		fingerprints = append(fingerprints, pgp.Fingerprint)
+	}
+	if len(recipients) > 0 {
+		args = append(args, "--age", strings.Join(recipients, ","))
+	}
+	if len(fingerprints) > 0 {
+		args = append(args, "--pgp", strings.Join(fingerprints, ","))
+	}
+	if meta.EncryptedRegex != "" {
+		args = append(args, "--encrypted-regex", meta.EncryptedRegex)
+	}
+	out, err := run(plain, "sops", append(args, "/dev/stdin")...)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting %s: %w", path, err)
+	}
+	return out, nil
+}
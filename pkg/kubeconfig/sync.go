@@ -0,0 +1,116 @@
+package kubeconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SyncExtension is the extension recording hashes of the field values last
+// taken from an incoming kubeconfig, so a later merge can tell local edits
+// from upstream changes.
+const SyncExtension = ExtensionPrefix + "sync"
+
+// FieldChange describes a field of a cluster or user whose incoming value
+// differs from the existing one.
+type FieldChange struct {
+	// Kind is "cluster" or "user".
+	Kind string
+	Name string
+	// Field is the kubeconfig field name, e.g. "server" or "token".
+	Field string
+	// Local and Incoming describe both values with secrets shortened.
+	Local    string
+	Incoming string
+	// LocalEmpty is set if the field is not set locally.
+	LocalEmpty bool
+	// Synced is set if the value of the last sync is known. LocalChanged
+	// and IncomingChanged then tell which side changed since.
+	Synced          bool
+	LocalChanged    bool
+	IncomingChanged bool
+}
+
+// Conflict reports whether both sides changed the field since the last sync.
+func (c FieldChange) Conflict() bool {
+	return c.Synced && c.LocalChanged && c.IncomingChanged
+}
+
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s %q %s", c.Kind, c.Name, c.Field)
+}
+
+// Resolver decides whether the incoming value of a field is taken. A nil
+// Resolver always takes it.
+type Resolver func(FieldChange) (bool, error)
+
+// fieldMerge merges the fields of one cluster or user, consulting resolve
+// for every differing field and collecting the hashes of the incoming
+// values for the sync extension.
+type fieldMerge struct {
+	kind, name string
+	resolve    Resolver
+	synced     map[string]string
+	changes    []string
+}
+
+func newFieldMerge(kind, name string, exts map[string]runtime.Object, resolve Resolver) (*fieldMerge, error) {
+	m := &fieldMerge{kind: kind, name: name, resolve: resolve, synced: map[string]string{}}
+	if _, err := GetExtension(exts, SyncExtension, &m.synced); err != nil {
+		return nil, fmt.Errorf("%s %q: %w", kind, name, err)
+	}
+	return m, nil
+}
+
+// take reports whether the incoming value of field replaces the local one.
+// The descriptions are used in conflicts and change messages.
+func (m *fieldMerge) take(field string, local, incoming interface{}, localDesc, incomingDesc string, localEmpty bool) (bool, error) {
+	localHash, incomingHash := valueHash(local), valueHash(incoming)
+	base, synced := m.synced[field]
+	m.synced[field] = incomingHash
+	if localHash == incomingHash {
+		return false, nil
+	}
+	change := FieldChange{
+		Kind:            m.kind,
+		Name:            m.name,
+		Field:           field,
+		Local:           localDesc,
+		Incoming:        incomingDesc,
+		LocalEmpty:      localEmpty,
+		Synced:          synced,
+		LocalChanged:    synced && localHash != base,
+		IncomingChanged: synced && incomingHash != base,
+	}
+	if m.resolve == nil {
+		return true, nil
+	}
+	take, err := m.resolve(change)
+	if err != nil {
+		return false, fmt.Errorf("resolving %s: %w", change, err)
+	}
+	if !take && change.Conflict() {
+		Debugf("keeping local %s despite incoming change", change)
+		m.changes = append(m.changes, fmt.Sprintf("Kept local %s %s (conflicting incoming value %s)", change, localDesc, incomingDesc))
+	}
+	return take, nil
+}
+
+// record stores the hashes of the incoming values in exts.
+func (m *fieldMerge) record(exts *map[string]runtime.Object) error {
+	return SetExtension(exts, SyncExtension, m.synced)
+}
+
+// valueHash returns a short hash of the JSON encoding of v. Only hashes of
+// credentials are stored, never the credentials themselves.
+func valueHash(v interface{}) string {
+	if b, ok := v.([]byte); ok && len(b) == 0 {
+		v = nil
+	}
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,168 @@
+package kubeconfig
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// syncedConfig returns a config whose user admin was last synced with the
+// token "synced-token" and since then set locally to localToken.
+func syncedConfig(t *testing.T, localToken string) *api.Config {
+	t.Helper()
+	cfg := api.NewConfig()
+	if _, err := MergeUser(cfg, "admin", &api.AuthInfo{Token: "synced-token"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	cfg.AuthInfos["admin"].Token = localToken
+	return cfg
+}
+
+func TestFieldMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		localToken  string
+		incoming    string
+		take        bool
+		wantChange  *FieldChange
+		wantToken   string
+		wantChanges []string
+	}{
+		{
+			name:       "unchanged local field takes incoming value",
+			localToken: "synced-token",
+			incoming:   "upstream-token",
+			take:       true,
+			wantChange: &FieldChange{Synced: true, IncomingChanged: true},
+			wantToken:  "upstream-token",
+			wantChanges: []string{
+				`Updated user "admin" token from synced-token to upstream-token`,
+			},
+		},
+		{
+			name:       "local edit without upstream change",
+			localToken: "local-token",
+			incoming:   "synced-token",
+			take:       false,
+			wantChange: &FieldChange{Synced: true, LocalChanged: true},
+			wantToken:  "local-token",
+		},
+		{
+			name:       "conflict taken",
+			localToken: "local-token",
+			incoming:   "upstream-token",
+			take:       true,
+			wantChange: &FieldChange{Synced: true, LocalChanged: true, IncomingChanged: true},
+			wantToken:  "upstream-token",
+			wantChanges: []string{
+				`Updated user "admin" token from local-token to upstream-token`,
+			},
+		},
+		{
+			name:       "conflict declined",
+			localToken: "local-token",
+			incoming:   "upstream-token",
+			take:       false,
+			wantChange: &FieldChange{Synced: true, LocalChanged: true, IncomingChanged: true},
+			wantToken:  "local-token",
+			wantChanges: []string{
+				`Kept local user "admin" token local-token (conflicting incoming value upstream-token)`,
+			},
+		},
+		{
+			name:       "same value",
+			localToken: "synced-token",
+			incoming:   "synced-token",
+			wantToken:  "synced-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := syncedConfig(t, tt.localToken)
+			var got []FieldChange
+			resolve := func(c FieldChange) (bool, error) {
+				got = append(got, c)
+				return tt.take, nil
+			}
+			changes, err := MergeUser(cfg, "admin", &api.AuthInfo{Token: tt.incoming}, resolve)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantChange == nil {
+				if len(got) != 0 {
+					t.Errorf("resolver called with %v", got)
+				}
+			} else {
+				if len(got) != 1 {
+					t.Fatalf("resolver called %d times, want once", len(got))
+				}
+				c := got[0]
+				if c.Field != "token" || c.Synced != tt.wantChange.Synced ||
+					c.LocalChanged != tt.wantChange.LocalChanged || c.IncomingChanged != tt.wantChange.IncomingChanged {
+					t.Errorf("resolver called with %+v, want %+v", c, *tt.wantChange)
+				}
+			}
+			if token := cfg.AuthInfos["admin"].Token; token != tt.wantToken {
+				t.Errorf("token = %s, want %s", token, tt.wantToken)
+			}
+			checkChanges(t, changes, tt.wantChanges)
+
+			// The incoming value becomes the base of the next merge, whether
+			// it was taken or not
+			var synced map[string]string
+			if _, err := GetExtension(cfg.AuthInfos["admin"].Extensions, SyncExtension, &synced); err != nil {
+				t.Fatal(err)
+			}
+			if synced["token"] != valueHash(tt.incoming) {
+				t.Errorf("recorded token hash %s, want hash of %s", synced["token"], tt.incoming)
+			}
+		})
+	}
+}
+
+func TestFieldMergeDeclinedConflictIsNotAskedAgain(t *testing.T) {
+	cfg := syncedConfig(t, "local-token")
+	decline := func(c FieldChange) (bool, error) { return false, nil }
+	if _, err := MergeUser(cfg, "admin", &api.AuthInfo{Token: "upstream-token"}, decline); err != nil {
+		t.Fatal(err)
+	}
+
+	var got FieldChange
+	resolve := func(c FieldChange) (bool, error) {
+		got = c
+		return false, nil
+	}
+	changes, err := MergeUser(cfg, "admin", &api.AuthInfo{Token: "upstream-token"}, resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Conflict() || !got.LocalChanged || got.IncomingChanged {
+		t.Errorf("second merge saw %+v, want only a local change", got)
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes = %q, want none", changes)
+	}
+}
+
+func TestFieldMergeNewEntrySkipsResolver(t *testing.T) {
+	cfg := api.NewConfig()
+	resolve := func(c FieldChange) (bool, error) {
+		t.Errorf("resolver called for %s of a new entry", c)
+		return false, nil
+	}
+	changes, err := MergeCluster(cfg, "prod", &api.Cluster{Server: "https://prod:6443", CertificateAuthorityData: []byte("ca")}, false, resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MergeUser(cfg, "admin", &api.AuthInfo{Token: "token"}, resolve); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || !strings.HasPrefix(changes[0], `Added cluster "prod"`) {
+		t.Errorf("changes = %q", changes)
+	}
+	if cfg.Clusters["prod"].Server != "https://prod:6443" || cfg.AuthInfos["admin"].Token != "token" {
+		t.Errorf("new entries not added as given")
+	}
+}
@@ -0,0 +1,63 @@
+package kubeconfig
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// TagsExtension is the context extension holding the tags of a context,
+// such as team, environment or region.
+const TagsExtension = ExtensionPrefix + "tags"
+
+// GetTags returns the tags of ctx. Tags without a value map to "".
+func GetTags(ctx *api.Context) (map[string]string, error) {
+	tags := map[string]string{}
+	if _, err := GetExtension(ctx.Extensions, TagsExtension, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SetTags replaces the tags of ctx, removing the extension if tags is empty.
+func SetTags(ctx *api.Context, tags map[string]string) error {
+	if len(tags) == 0 {
+		delete(ctx.Extensions, TagsExtension)
+		return nil
+	}
+	return SetExtension(&ctx.Extensions, TagsExtension, tags)
+}
+
+// ParseTag splits a tag given as key=value, or just key, into key and value.
+func ParseTag(tag string) (string, string) {
+	key, value, _ := strings.Cut(tag, "=")
+	return strings.TrimSpace(key), strings.TrimSpace(value)
+}
+
+// FormatTags returns tags as sorted, comma separated key=value pairs.
+func FormatTags(tags map[string]string) string {
+	var pairs []string
+	for key, value := range tags {
+		if value == "" {
+			pairs = append(pairs, key)
+		} else {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// MatchTags reports whether tags match all selectors. A selector key=value
+// requires the tag with that value, a bare key the tag with any value.
+func MatchTags(tags map[string]string, selectors []string) bool {
+	for _, selector := range selectors {
+		key, value := ParseTag(selector)
+		actual, exists := tags[key]
+		if !exists || (strings.Contains(selector, "=") && actual != value) {
+			return false
+		}
+	}
+	return true
+}
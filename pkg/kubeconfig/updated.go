@@ -0,0 +1,71 @@
+package kubeconfig
+
+import (
+	"reflect"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// LastUpdatedExtension is the context extension recording when and from
+// where the credentials of a context were last updated.
+const LastUpdatedExtension = ExtensionPrefix + "last-updated"
+
+// LastUpdated is the content of the last-updated extension.
+type LastUpdated struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source,omitempty"`
+}
+
+// GetLastUpdated returns the last-updated stamp of ctx and whether it has
+// one.
+func GetLastUpdated(ctx *api.Context) (LastUpdated, bool, error) {
+	var stamp LastUpdated
+	found, err := GetExtension(ctx.Extensions, LastUpdatedExtension, &stamp)
+	return stamp, found, err
+}
+
+// StampUpdated records stamp on every context of after that is new or whose
+// cluster or user differs from before. Changes to the context alone, such as
+// its namespace or tags, leave the credentials as they were and are not
+// stamped. It returns the names of the stamped contexts.
+func StampUpdated(before, after *api.Config, stamp LastUpdated) ([]string, error) {
+	var stamped []string
+	for name, ctx := range after.Contexts {
+		old, exists := before.Contexts[name]
+		if exists && old.Cluster == ctx.Cluster && old.AuthInfo == ctx.AuthInfo &&
+			sameCluster(before.Clusters[old.Cluster], after.Clusters[ctx.Cluster]) &&
+			sameUser(before.AuthInfos[old.AuthInfo], after.AuthInfos[ctx.AuthInfo]) {
+			continue
+		}
+		if err := SetExtension(&ctx.Extensions, LastUpdatedExtension, stamp); err != nil {
+			return nil, err
+		}
+		stamped = append(stamped, name)
+	}
+	return stamped, nil
+}
+
+// sameCluster compares two clusters, ignoring where they were loaded from
+// and their extensions.
+func sameCluster(a, b *api.Cluster) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	a, b = a.DeepCopy(), b.DeepCopy()
+	a.LocationOfOrigin, b.LocationOfOrigin = "", ""
+	a.Extensions, b.Extensions = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// sameUser compares two users, ignoring where they were loaded from and
+// their extensions.
+func sameUser(a, b *api.AuthInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	a, b = a.DeepCopy(), b.DeepCopy()
+	a.LocationOfOrigin, b.LocationOfOrigin = "", ""
+	a.Extensions, b.Extensions = nil, nil
+	return reflect.DeepEqual(a, b)
+}
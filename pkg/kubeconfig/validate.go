@@ -0,0 +1,230 @@
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is a problem with one field of a kubeconfig document.
+type FieldError struct {
+	// Path locates the field, e.g. users[0](admin).user.client-key-data.
+	Path    string
+	Line    int
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Path, e.Message)
+}
+
+// ValidationError lists all problems found by Validate.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return "invalid kubeconfig:\n  " + strings.Join(messages, "\n  ")
+}
+
+// knownFields are the keys allowed in each part of a kubeconfig.
+var knownFields = map[string][]string{
+	"config":  {"apiVersion", "kind", "preferences", "clusters", "users", "contexts", "current-context", "extensions"},
+	"cluster": {"server", "tls-server-name", "insecure-skip-tls-verify", "certificate-authority", "certificate-authority-data", "proxy-url", "disable-compression", "extensions"},
+	"user": {"client-certificate", "client-certificate-data", "client-key", "client-key-data", "token", "tokenFile",
+		"as", "as-uid", "as-groups", "as-user-extra", "username", "password", "auth-provider", "exec", "extensions"},
+	"context": {"cluster", "user", "namespace", "extensions"},
+}
+
+// requiredFields are the keys each part of a kubeconfig must set.
+var requiredFields = map[string][]string{
+	"cluster": {"server"},
+	"context": {"cluster", "user"},
+}
+
+// pemFields are the -data fields and the PEM block type they must contain.
+var pemFields = map[string]string{
+	"certificate-authority-data": "CERTIFICATE",
+	"client-certificate-data":    "CERTIFICATE",
+	"client-key-data":            "PRIVATE KEY",
+}
+
+// Validate checks a kubeconfig document against the kubeconfig schema:
+// apiVersion and kind, known and required fields, named list entries and
+// base64 encoded PEM data in the -data fields. Unlike parsing with
+// clientcmd, it reports every problem with the path and line of the field.
+func Validate(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid kubeconfig: not valid YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return fmt.Errorf("invalid kubeconfig: document is empty")
+	}
+	var errs ValidationError
+	fail := func(node *yaml.Node, path, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Path: path, Line: node.Line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		fail(root, "(document)", "expected a mapping with apiVersion, kind, clusters, users and contexts")
+		return errs
+	}
+	fields := checkMapping(root, "", "config", fail)
+	if node, ok := fields["apiVersion"]; !ok {
+		fail(root, "apiVersion", "missing, expected v1")
+	} else if node.Value != "v1" {
+		fail(node, "apiVersion", "is %q, expected v1", node.Value)
+	}
+	if node, ok := fields["kind"]; !ok {
+		fail(root, "kind", "missing, expected Config")
+	} else if node.Value != "Config" {
+		fail(node, "kind", "is %q, expected Config", node.Value)
+	}
+
+	for _, list := range []struct{ key, item string }{{"clusters", "cluster"}, {"users", "user"}, {"contexts", "context"}} {
+		node, ok := fields[list.key]
+		if !ok || node.Tag == "!!null" {
+			continue
+		}
+		if node.Kind != yaml.SequenceNode {
+			fail(node, list.key, "expected a list of entries with name and %s", list.item)
+			continue
+		}
+		for i, entry := range node.Content {
+			path := fmt.Sprintf("%s[%d]", list.key, i)
+			if entry.Kind != yaml.MappingNode {
+				fail(entry, path, "expected an entry with name and %s", list.item)
+				continue
+			}
+			entryFields := map[string]*yaml.Node{}
+			for j := 0; j+1 < len(entry.Content); j += 2 {
+				key := entry.Content[j].Value
+				entryFields[key] = entry.Content[j+1]
+				if key != "name" && key != list.item {
+					fail(entry.Content[j], path+"."+key, "unknown field, expected name and %s", list.item)
+				}
+			}
+			name, ok := entryFields["name"]
+			if !ok || name.Value == "" {
+				fail(entry, path+".name", "missing")
+			} else {
+				path += "(" + name.Value + ")"
+			}
+			body, ok := entryFields[list.item]
+			if !ok {
+				fail(entry, path+"."+list.item, "missing")
+				continue
+			}
+			if body.Kind != yaml.MappingNode {
+				fail(body, path+"."+list.item, "expected a mapping")
+				continue
+			}
+			checkMapping(body, path+"."+list.item, list.item, fail)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkMapping checks the keys of a mapping node of the given part of a
+// kubeconfig and returns its fields by key.
+func checkMapping(node *yaml.Node, path, part string, fail func(*yaml.Node, string, string, ...interface{})) map[string]*yaml.Node {
+	fieldPath := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	fields := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if _, dup := fields[key.Value]; dup {
+			fail(key, fieldPath(key.Value), "set more than once")
+		}
+		fields[key.Value] = value
+		if !slices.Contains(knownFields[part], key.Value) {
+			fail(key, fieldPath(key.Value), "unknown field%s", suggestField(key.Value, knownFields[part]))
+			continue
+		}
+		if blockType, ok := pemFields[key.Value]; ok {
+			checkPEMData(value, fieldPath(key.Value), blockType, fail)
+		}
+	}
+	for _, key := range requiredFields[part] {
+		if value, ok := fields[key]; !ok || value.Value == "" {
+			fail(node, fieldPath(key), "missing")
+		}
+	}
+	return fields
+}
+
+// checkPEMData checks that a -data field is base64 encoded PEM with a block
+// of the given type.
+func checkPEMData(node *yaml.Node, path, blockType string, fail func(*yaml.Node, string, string, ...interface{})) {
+	if node.Kind != yaml.ScalarNode || node.Value == "" {
+		return
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+	if err != nil {
+		if strings.Contains(node.Value, "-----BEGIN") {
+			fail(node, path, "contains plain PEM, it must be base64 encoded (base64 -w0 < file)")
+		} else {
+			fail(node, path, "is not valid base64: %v", err)
+		}
+		return
+	}
+	block, _ := pem.Decode(decoded)
+	if block == nil {
+		fail(node, path, "does not decode to PEM data")
+		return
+	}
+	if !strings.HasSuffix(block.Type, blockType) {
+		fail(node, path, "contains a PEM %s block, expected %s", block.Type, blockType)
+	}
+}
+
+// suggestField returns a hint naming the known field closest to key, if
+// any is close.
+func suggestField(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %s?", best)
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,106 @@
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	caData := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("ca")}))
+	valid := `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod:6443
+    certificate-authority-data: ` + caData + `
+users:
+- name: admin
+  user:
+    token: abc
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+`
+	tests := []struct {
+		name string
+		data string
+		// want are the expected errors as formatted by FieldError.
+		want []string
+	}{
+		{
+			name: "valid",
+			data: valid,
+		},
+		{
+			name: "unknown field with suggestion",
+			data: strings.Replace(valid, "    token: abc", "    tokn: abc", 1),
+			want: []string{"line 11: users[0](admin).user.tokn: unknown field, did you mean token?"},
+		},
+		{
+			name: "unknown field without suggestion",
+			data: strings.Replace(valid, "    token: abc", "    password-file: abc", 1),
+			want: []string{"line 11: users[0](admin).user.password-file: unknown field"},
+		},
+		{
+			name: "plain PEM in data field",
+			data: strings.Replace(valid, caData, `"-----BEGIN CERTIFICATE-----"`, 1),
+			want: []string{"line 7: clusters[0](prod).cluster.certificate-authority-data: contains plain PEM, it must be base64 encoded (base64 -w0 < file)"},
+		},
+		{
+			name: "wrong PEM block type",
+			data: strings.Replace(valid, caData,
+				base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("key")})), 1),
+			want: []string{"line 7: clusters[0](prod).cluster.certificate-authority-data: contains a PEM RSA PRIVATE KEY block, expected CERTIFICATE"},
+		},
+		{
+			name: "missing server",
+			data: strings.Replace(valid, "    server: https://prod:6443\n", "", 1),
+			want: []string{"line 6: clusters[0](prod).cluster.server: missing"},
+		},
+		{
+			name: "missing name",
+			data: strings.Replace(valid, "- name: admin\n  user:", "- user:", 1),
+			want: []string{"line 9: users[0].name: missing"},
+		},
+		{
+			name: "wrong kind",
+			data: strings.Replace(valid, "kind: Config", "kind: Pod", 1),
+			want: []string{`line 2: kind: is "Pod", expected Config`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]byte(tt.data))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("error = %v, want a ValidationError", err)
+			}
+			var got []string
+			for _, fieldErr := range verr {
+				got = append(got, fieldErr.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateNotYAML(t *testing.T) {
+	err := Validate([]byte("apiVersion: [v1"))
+	if err == nil || !strings.Contains(err.Error(), "not valid YAML") {
+		t.Errorf("error = %v, want not valid YAML", err)
+	}
+}
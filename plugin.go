@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pluginMode reports whether the binary runs as kubectl plugin, i.e. was
+// invoked as kubectl-config_update (directly or through a krew symlink).
+func pluginMode() bool {
+	return strings.HasPrefix(filepath.Base(os.Args[0]), "kubectl-")
+}
+
+// programName returns how the user invoked the binary, for usage output.
+func programName() string {
+	if !pluginMode() {
+		return os.Args[0]
+	}
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	return "kubectl " + strings.ReplaceAll(strings.TrimPrefix(name, "kubectl-"), "_", "-")
+}
+
+// applyKubectlConventions makes the plugin behave like kubectl: the
+// kubeconfig defaults to the first file in $KUBECONFIG, and --kubeconfig is
+// accepted in place of --config.
+func applyKubectlConventions() {
+	if paths := filepath.SplitList(os.Getenv("KUBECONFIG")); len(paths) > 0 && userSettings.Kubeconfig == defaultConfigPath {
+		userSettings.Kubeconfig = paths[0]
+	}
+	for i, arg := range os.Args {
+		switch {
+		case arg == "--kubeconfig" || arg == "-kubeconfig":
+			os.Args[i] = "--config"
+		case strings.HasPrefix(arg, "--kubeconfig="), strings.HasPrefix(arg, "-kubeconfig="):
+			os.Args[i] = "--config=" + arg[strings.Index(arg, "=")+1:]
+		}
+	}
+}
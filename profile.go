@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// profile is a named, repeatable update defined in the settings file. It
+// either runs an import source with fixed arguments, or fetches a kubeconfig
+// from one of url, file, vault or op and applies it to context.
+type profile struct {
+	Import string   `yaml:"import"`
+	Args   []string `yaml:"args"`
+
+	URL        string            `yaml:"url"`
+	Headers    map[string]string `yaml:"headers"`
+	File       string            `yaml:"file"`
+	Vault      string            `yaml:"vault"`
+	VaultField string            `yaml:"vault-field"`
+	Op         string            `yaml:"op"`
+
+	// Context is the context to update. Cluster and User pick the entries
+	// of the fetched kubeconfig if it has several.
+	Context      string `yaml:"context"`
+	Cluster      string `yaml:"cluster"`
+	User         string `yaml:"user"`
+	UpdateServer bool   `yaml:"update-server"`
+	Namespace    string `yaml:"namespace"`
+
+	// Every is how often the daemon runs the profile, e.g. "12h".
+	Every time.Duration `yaml:"every"`
+}
+
+func runProfile(args []string) error {
+	fs, configPathFlag := newFlagSet("run")
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print output")
+	addStrategyFlag(fs)
+	addNotifyFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one profile name, defined profiles: %s",
+			quoteList(sortedNames(userSettings.Profiles)))
+	}
+	name := fs.Arg(0)
+	p, exists := userSettings.Profiles[name]
+	if !exists {
+		return fmt.Errorf("profile %s not found in settings", name)
+	}
+	audit.source = "run " + name
+
+	if p.Import != "" {
+		importArgs := append([]string{p.Import}, sharedFlagArgs()...)
+		importArgs = append(importArgs, "--config", *configPathFlag)
+		if *tryFlag {
+			importArgs = append(importArgs, "--try")
+		}
+		return runImport(append(importArgs, p.Args...))
+	}
+	if p.Context == "" {
+		return fmt.Errorf("profile %s has no context to update", name)
+	}
+	audit.context = p.Context
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	var changes []string
+	ctx, exists := cfg.Contexts[p.Context]
+	if !exists {
+		ctx = &api.Context{Cluster: p.Context, AuthInfo: p.Context}
+		cfg.Contexts[p.Context] = ctx
+		changes = append(changes, fmt.Sprintf("Added context %q with cluster %q and user %q", p.Context, ctx.Cluster, ctx.AuthInfo))
+		p.UpdateServer = true
+	}
+
+	data, err := p.fetch(cfg, ctx)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	incoming, err := clientcmd.Load(data)
+	if err != nil {
+		return fmt.Errorf("profile %s: parsing fetched kubeconfig: %w", name, err)
+	}
+	cluster, user, err := p.pick(incoming, ctx)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	if warnings := expiredCredentials(user); len(warnings) > 0 {
+		return fmt.Errorf("profile %s: fetched credentials are expired: %s", name, strings.Join(warnings, ", "))
+	}
+
+	clusterChanges, err := kubeconfig.MergeCluster(cfg, ctx.Cluster, cluster, p.UpdateServer, resolveConflict)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	userChanges, err := kubeconfig.MergeUser(cfg, ctx.AuthInfo, user, resolveConflict)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	changes = append(changes, clusterChanges...)
+	changes = append(changes, userChanges...)
+	if p.Namespace != "" {
+		if err := validateNamespace(p.Namespace); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+		if change := kubeconfig.SetNamespace(p.Context, ctx, p.Namespace); change != "" {
+			changes = append(changes, change)
+		}
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// fetch reads the kubeconfig of the profile from its source.
+func (p profile) fetch(cfg *api.Config, ctx *api.Context) ([]byte, error) {
+	switch {
+	case p.URL != "":
+		header := http.Header{}
+		for key, value := range p.Headers {
+			header.Set(key, value)
+		}
+		return httpGet(p.URL, header)
+	case p.File != "":
+		path, err := expandPath(p.File)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadFile(path)
+	case p.Vault != "":
+		field := p.VaultField
+		if field == "" {
+			field = "kubeconfig"
+		}
+		return readVaultKubeconfig(p.Vault, field, ctx.Cluster, ctx.AuthInfo)
+	case p.Op != "":
+		return readOnePasswordKubeconfig(p.Op, cfg, ctx)
+	}
+	return nil, fmt.Errorf("no source, set one of import, url, file, vault or op")
+}
+
+// pick returns the cluster and user of incoming to apply to ctx: the ones
+// named in the profile, the ones named like those of ctx, or the only ones.
+func (p profile) pick(incoming *api.Config, ctx *api.Context) (*api.Cluster, *api.AuthInfo, error) {
+	clusterName := firstExisting(incoming.Clusters, p.Cluster, ctx.Cluster)
+	cluster, exists := incoming.Clusters[clusterName]
+	if !exists {
+		return nil, nil, fmt.Errorf("cannot tell which of the clusters %s to use, set cluster in the profile",
+			quoteList(sortedNames(incoming.Clusters)))
+	}
+	userName := firstExisting(incoming.AuthInfos, p.User, ctx.AuthInfo)
+	if _, exists := incoming.AuthInfos[userName]; !exists {
+		for _, c := range incoming.Contexts {
+			if c.Cluster == clusterName {
+				userName = c.AuthInfo
+				break
+			}
+		}
+	}
+	user, exists := incoming.AuthInfos[userName]
+	if !exists {
+		return nil, nil, fmt.Errorf("cannot tell which of the users %s to use, set user in the profile",
+			quoteList(sortedNames(incoming.AuthInfos)))
+	}
+	return cluster, user, nil
+}
+
+// firstExisting returns the first of names that is a key of m, or the only
+// key of m.
+func firstExisting[T any](m map[string]T, names ...string) string {
+	for _, name := range names {
+		if _, exists := m[name]; exists && name != "" {
+			return name
+		}
+	}
+	if len(m) == 1 {
+		for name := range m {
+			return name
+		}
+	}
+	return ""
+}
+
+// sortedNames returns the sorted keys of m.
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
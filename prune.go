@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runPrune(args []string) error {
+	fs, configPathFlag := newFlagSet("prune")
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print what would be removed")
+	tagFlag := addTagFlag(fs, "Also remove the contexts with this tag")
+	removeExpiredFlag := fs.Bool("remove-expired", false, "Also remove contexts whose credentials expired, after confirmation")
+	expiredFor := dayDuration(30 * 24 * time.Hour)
+	fs.Var(&expiredFor, "expired-for", "With --remove-expired, only remove credentials expired for longer than this")
+	yesFlag := fs.Bool("yes", false, "Do not ask for confirmation")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var remove []string
+	if len(*tagFlag) > 0 {
+		for _, name := range sortedNames(cfg.Contexts) {
+			if contextMatches(cfg, name, *tagFlag) {
+				remove = append(remove, name)
+			}
+		}
+	}
+	if *removeExpiredFlag {
+		expired := expiredContexts(cfg, time.Duration(expiredFor))
+		if len(expired) > 0 && !*tryFlag && !*yesFlag {
+			if expired, err = confirmExpiredRemoval(cfg, expired); err != nil {
+				return err
+			}
+		}
+		remove = append(remove, expired...)
+	}
+
+	var changes []string
+	for _, name := range remove {
+		if _, exists := cfg.Contexts[name]; !exists {
+			continue // both tagged and expired
+		}
+		contextChanges, err := deleteContext(cfg, name)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, contextChanges...)
+	}
+	changes = append(changes, pruneConfig(cfg)...)
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// pruneConfig removes clusters and users that no context references.
+func pruneConfig(cfg *api.Config) []string {
+	var changes []string
+	for _, name := range sortedNames(cfg.Clusters) {
+		if len(contextsUsingCluster(cfg, name)) == 0 {
+			changes = append(changes, fmt.Sprintf("Removed unused cluster %q with server %s", name, cfg.Clusters[name].Server))
+			delete(cfg.Clusters, name)
+		}
+	}
+	for _, name := range sortedNames(cfg.AuthInfos) {
+		if len(contextsUsingUser(cfg, name)) == 0 {
+			changes = append(changes, fmt.Sprintf("Removed unused user %q", name))
+			delete(cfg.AuthInfos, name)
+		}
+	}
+	return changes
+}
+
+// expiredContexts returns the sorted names of the contexts whose user has a
+// credential that expired longer than expiredFor ago.
+func expiredContexts(cfg *api.Config, expiredFor time.Duration) []string {
+	var names []string
+	for _, name := range sortedNames(cfg.Contexts) {
+		user, exists := cfg.AuthInfos[cfg.Contexts[name].AuthInfo]
+		if !exists {
+			continue
+		}
+		if expiry, ok := credentialExpiry(user); ok && time.Since(expiry) > expiredFor {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// confirmExpiredRemoval asks which of the expired contexts to remove, all of
+// them selected initially.
+func confirmExpiredRemoval(cfg *api.Config, expired []string) ([]string, error) {
+	options := make([]huh.Option[string], len(expired))
+	for i, name := range expired {
+		expiry, _ := credentialExpiry(cfg.AuthInfos[cfg.Contexts[name].AuthInfo])
+		options[i] = huh.NewOption(fmt.Sprintf("%s (%s)", name, kubeconfig.DescribeExpiry(expiry)), name).Selected(true)
+	}
+	var selected []string
+	err := runForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Remove these contexts with expired credentials?").
+				Options(options...).
+				Value(&selected),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("confirming removal of expired contexts: %w", err)
+	}
+	return selected, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"gopkg.in/yaml.v3"
+)
+
+var secretFieldPattern = regexp.MustCompile(kubeconfig.SecretFieldsRegex)
+
+// redactSecrets shortens the values of all credential fields in the
+// serialized kubeconfig data, so it can be printed without leaking tokens
+// and keys.
+func redactSecrets(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	redactNode(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("marshaling redacted kubeconfig: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func redactNode(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && secretFieldPattern.MatchString(key.Value) {
+				value.Value = kubeconfig.Shorten(value.Value)
+			}
+		}
+	}
+	for _, child := range node.Content {
+		redactNode(child)
+	}
+}
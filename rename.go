@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runRename(args []string) error {
+	fs, configPathFlag := newFlagSet("rename")
+	tryFlag := fs.Bool("try", false, "Try mode: do not update file, just print what would change")
+	clusterFlag := fs.String("cluster", "", "Also rename the context's cluster to this name")
+	userFlag := fs.String("user", "", "Also rename the context's user to this name")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var oldName, newName string
+	newCluster, newUser := *clusterFlag, *userFlag
+	switch fs.NArg() {
+	case 0:
+		oldName, err = selectContext(cfg, "Select a context to rename")
+		if err != nil {
+			return fmt.Errorf("selecting context: %w", err)
+		}
+		ctx := cfg.Contexts[oldName]
+		newName, newCluster, newUser = oldName, ctx.Cluster, ctx.AuthInfo
+		err = runForm(
+			huh.NewGroup(
+				huh.NewInput().
+					Title("Enter new context name").
+					Value(&newName),
+				huh.NewInput().
+					Title("Enter new cluster name").
+					Value(&newCluster),
+				huh.NewInput().
+					Title("Enter new user name").
+					Value(&newUser),
+			),
+		)
+		if err != nil {
+			return fmt.Errorf("getting new names: %w", err)
+		}
+	case 2:
+		oldName, newName = fs.Arg(0), fs.Arg(1)
+	default:
+		return fmt.Errorf("expected <old-name> <new-name>, got %d arguments", fs.NArg())
+	}
+
+	changes, err := renameContext(cfg, oldName, newName, newCluster, newUser)
+	if err != nil {
+		return err
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// renameContext renames the context oldName to newName and updates
+// current-context if it pointed at the old name. If newCluster or newUser
+// are non-empty, the context's cluster and user entries are renamed as well
+// and every context referencing them is updated accordingly.
+func renameContext(cfg *api.Config, oldName, newName, newCluster, newUser string) ([]string, error) {
+	ctx, exists := cfg.Contexts[oldName]
+	if !exists {
+		return nil, fmt.Errorf("context %s not found", oldName)
+	}
+	if newName == "" {
+		return nil, fmt.Errorf("new context name must not be empty")
+	}
+
+	var changes []string
+	if newName != oldName {
+		if _, exists := cfg.Contexts[newName]; exists {
+			return nil, fmt.Errorf("context %s already exists", newName)
+		}
+		delete(cfg.Contexts, oldName)
+		cfg.Contexts[newName] = ctx
+		changes = append(changes, fmt.Sprintf("Renamed context %q to %q", oldName, newName))
+		if cfg.CurrentContext == oldName {
+			cfg.CurrentContext = newName
+			changes = append(changes, fmt.Sprintf("Updated current-context to %q", newName))
+		}
+	}
+
+	if newCluster != "" && newCluster != ctx.Cluster {
+		oldCluster := ctx.Cluster
+		if _, exists := cfg.Clusters[newCluster]; exists {
+			return nil, fmt.Errorf("cluster %s already exists", newCluster)
+		}
+		if cluster, exists := cfg.Clusters[oldCluster]; exists {
+			delete(cfg.Clusters, oldCluster)
+			cfg.Clusters[newCluster] = cluster
+		}
+		for _, name := range contextsUsingCluster(cfg, oldCluster) {
+			cfg.Contexts[name].Cluster = newCluster
+		}
+		changes = append(changes, fmt.Sprintf("Renamed cluster %q to %q", oldCluster, newCluster))
+	}
+
+	if newUser != "" && newUser != ctx.AuthInfo {
+		oldUser := ctx.AuthInfo
+		if _, exists := cfg.AuthInfos[newUser]; exists {
+			return nil, fmt.Errorf("user %s already exists", newUser)
+		}
+		if user, exists := cfg.AuthInfos[oldUser]; exists {
+			delete(cfg.AuthInfos, oldUser)
+			cfg.AuthInfos[newUser] = user
+		}
+		for _, name := range contextsUsingUser(cfg, oldUser) {
+			cfg.Contexts[name].AuthInfo = newUser
+		}
+		changes = append(changes, fmt.Sprintf("Renamed user %q to %q", oldUser, newUser))
+	}
+	return changes, nil
+}
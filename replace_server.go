@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runReplaceServer(args []string) error {
+	fs, configPathFlag := newFlagSet("replace-server")
+	tryFlag := fs.Bool("try", false, "Try mode: only print the diff")
+	yesFlag := fs.Bool("yes", false, "Do not ask for confirmation")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return fmt.Errorf("expected a pattern and its replacement")
+	}
+	re, err := regexp.Compile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	updated := cfg.DeepCopy()
+	changes := replaceServers(updated, re, fs.Arg(1), true)
+	if len(changes) == 0 {
+		infof("No server URL matches %s\n", re)
+		return nil
+	}
+	proceed, err := previewChanges(configPath, cfg, updated, *tryFlag || *yesFlag)
+	if err != nil || !proceed {
+		return err
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	return saveConfig(configPath, origData, updated, changes)
+}
+
+// previewChanges prints the diff between before and after, which is about to
+// be written to configPath, and asks whether to go ahead unless noConfirm is
+// set. In quiet mode the diff is only printed when asking.
+func previewChanges(configPath string, before, after *api.Config, noConfirm bool) (bool, error) {
+	if noConfirm && quietEnabled {
+		return true, nil
+	}
+	a, err := configLines(before, false)
+	if err != nil {
+		return false, err
+	}
+	b, err := configLines(after, false)
+	if err != nil {
+		return false, err
+	}
+	fmt.Print(unifiedDiff(configPath, configPath+" (updated)", a, b))
+	if noConfirm {
+		return true, nil
+	}
+	var proceed bool
+	err = runForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Write these changes to %s?", configPath)).
+				Value(&proceed),
+		),
+	)
+	if err != nil {
+		return false, fmt.Errorf("getting confirmation: %w", err)
+	}
+	if !proceed {
+		infof("Aborted.\n")
+	}
+	return proceed, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+)
+
+// tokenRequest is the subset of the authentication.k8s.io/v1 TokenRequest
+// resource used to mint ServiceAccount tokens.
+type tokenRequest struct {
+	APIVersion string              `json:"apiVersion"`
+	Kind       string              `json:"kind"`
+	Spec       tokenRequestSpec    `json:"spec"`
+	Status     *tokenRequestStatus `json:"status,omitempty"`
+}
+
+type tokenRequestSpec struct {
+	Audiences         []string `json:"audiences,omitempty"`
+	ExpirationSeconds int64    `json:"expirationSeconds,omitempty"`
+}
+
+type tokenRequestStatus struct {
+	Token               string    `json:"token"`
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+}
+
+func runRotateToken(args []string) error {
+	fs, configPathFlag := newFlagSet("rotate-token")
+	tryFlag := fs.Bool("try", false, "Try mode: request a token but do not update file")
+	saFlag := fs.String("service-account", "", "ServiceAccount to request a token for, as namespace/name")
+	durationFlag := fs.Duration("duration", 24*time.Hour, "Requested token lifetime")
+	audienceFlag := fs.String("audience", "", "Comma separated token audiences (default: API server audience)")
+	fs.Parse(args)
+
+	namespace, name, ok := strings.Cut(*saFlag, "/")
+	if !ok || namespace == "" || name == "" {
+		return fmt.Errorf("--service-account must be given as namespace/name")
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var contextName string
+	switch fs.NArg() {
+	case 0:
+		contextName, err = selectContext(cfg, "Select a context to rotate the token of")
+		if err != nil {
+			return fmt.Errorf("selecting context: %w", err)
+		}
+	case 1:
+		contextName = fs.Arg(0)
+	default:
+		return fmt.Errorf("expected at most one context name, got %d", fs.NArg())
+	}
+	ctx, exists := cfg.Contexts[contextName]
+	if !exists {
+		return fmt.Errorf("context %s not found", contextName)
+	}
+	user, exists := cfg.AuthInfos[ctx.AuthInfo]
+	if !exists {
+		return fmt.Errorf("user %s not found", ctx.AuthInfo)
+	}
+
+	req := tokenRequest{
+		APIVersion: "authentication.k8s.io/v1",
+		Kind:       "TokenRequest",
+		Spec:       tokenRequestSpec{ExpirationSeconds: int64(durationFlag.Seconds())},
+	}
+	if *audienceFlag != "" {
+		req.Spec.Audiences = strings.Split(*audienceFlag, ",")
+	}
+	var resp tokenRequest
+	path := fmt.Sprintf("/api/v1/namespaces/%s/serviceaccounts/%s/token", namespace, name)
+	if err := kubeAPIRequest(cfg, contextName, "POST", path, req, &resp); err != nil {
+		return fmt.Errorf("requesting token for %s: %w", *saFlag, err)
+	}
+	if resp.Status == nil || resp.Status.Token == "" {
+		return fmt.Errorf("token request for %s returned no token", *saFlag)
+	}
+
+	changes := []string{
+		fmt.Sprintf("Updated user %q token from %s to %s",
+			ctx.AuthInfo, kubeconfig.DescribeToken(user.Token), kubeconfig.DescribeToken(resp.Status.Token)),
+		fmt.Sprintf("New token for ServiceAccount %s expires %s (%s)", *saFlag,
+			resp.Status.ExpirationTimestamp.Format(time.RFC3339), kubeconfig.DescribeExpiry(resp.Status.ExpirationTimestamp)),
+	}
+	user.Token = resp.Status.Token
+	user.TokenFile = ""
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
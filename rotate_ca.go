@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runRotateCA(args []string) error {
+	fs, configPathFlag := newFlagSet("rotate-ca")
+	tryFlag := fs.Bool("try", false, "Try mode: only print the diff")
+	yesFlag := fs.Bool("yes", false, "Do not ask for confirmation")
+	caFlag := fs.String("ca", "", "File with the new CA certificate(s) as PEM or base64 encoded PEM, or - for stdin")
+	serverFlag := fs.String("server", "", "Rotate clusters whose server URL matches this regular expression")
+	fingerprintFlag := fs.String("fingerprint", "", "Rotate clusters whose current CA has this SHA-256 fingerprint (or a prefix of it)")
+	fs.Parse(args)
+
+	if *caFlag == "" {
+		return fmt.Errorf("--ca is required")
+	}
+	if *serverFlag == "" && *fingerprintFlag == "" {
+		return fmt.Errorf("--server or --fingerprint is required to select the clusters")
+	}
+	var serverPattern *regexp.Regexp
+	if *serverFlag != "" {
+		var err error
+		if serverPattern, err = regexp.Compile(*serverFlag); err != nil {
+			return fmt.Errorf("invalid --server: %w", err)
+		}
+	}
+	caData, err := readCA(*caFlag)
+	if err != nil {
+		return err
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	updated := cfg.DeepCopy()
+	var changes []string
+	for _, name := range sortedNames(updated.Clusters) {
+		cluster := updated.Clusters[name]
+		if serverPattern != nil && !serverPattern.MatchString(cluster.Server) {
+			continue
+		}
+		if *fingerprintFlag != "" && !hasCAFingerprint(cluster, *fingerprintFlag) {
+			continue
+		}
+		if bytes.Equal(cluster.CertificateAuthorityData, caData) && cluster.CertificateAuthority == "" {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("Updated cluster %q CA data from %s to %s%s", name,
+			kubeconfig.ShortenBytes(cluster.CertificateAuthorityData), kubeconfig.ShortenBytes(caData), kubeconfig.CertSummary(caData)))
+		cluster.CertificateAuthorityData = caData
+		cluster.CertificateAuthority = ""
+		cluster.InsecureSkipTLSVerify = false
+	}
+	if len(changes) == 0 {
+		infof("No cluster needs the new CA\n")
+		return nil
+	}
+	proceed, err := previewChanges(configPath, cfg, updated, *tryFlag || *yesFlag)
+	if err != nil || !proceed {
+		return err
+	}
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: kubeconfig not modified.\n")
+		return nil
+	}
+	return saveConfig(configPath, origData, updated, changes)
+}
+
+// readCA reads PEM certificates, possibly base64 encoded as in kubeconfigs,
+// from the file at path.
+func readCA(path string) ([]byte, error) {
+	data, err := readInputFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		if data, err = decodeBase64(data); err != nil {
+			return nil, fmt.Errorf("%s is neither PEM nor base64 encoded PEM", path)
+		}
+	}
+	if len(kubeconfig.ParseCertificates(data)) == 0 {
+		return nil, fmt.Errorf("%s contains no certificate", path)
+	}
+	return data, nil
+}
+
+// hasCAFingerprint reports whether one of the CA certificates of cluster,
+// inline or from its file, has the given SHA-256 fingerprint. Colons and
+// case are ignored, and a prefix of the fingerprint suffices.
+func hasCAFingerprint(cluster *api.Cluster, fingerprint string) bool {
+	caData := cluster.CertificateAuthorityData
+	if len(caData) == 0 && cluster.CertificateAuthority != "" {
+		var err error
+		if caData, err = os.ReadFile(cluster.CertificateAuthority); err != nil {
+			debugf("reading CA file %s: %v", cluster.CertificateAuthority, err)
+			return false
+		}
+	}
+	normalize := func(s string) string {
+		return strings.ToUpper(strings.ReplaceAll(s, ":", ""))
+	}
+	want := normalize(fingerprint)
+	for _, cert := range kubeconfig.ParseCertificates(caData) {
+		if strings.HasPrefix(normalize(certFingerprint(cert)), want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+//go:embed ui/index.html
+var webUI []byte
+
+// apiServer serves the local HTTP API on top of one kubeconfig file.
+// Requests are serialized, as every modifying request rewrites the file.
+type apiServer struct {
+	configPath string
+	token      string
+	mu         sync.Mutex
+}
+
+func runServe(args []string) error {
+	fs, configPathFlag := newFlagSet("serve")
+	listenFlag := fs.String("listen", "127.0.0.1:8086", "Address to listen on")
+	tokenFlag := fs.String("token", "", "Bearer token clients must send (default: random)")
+	uiFlag := fs.Bool("ui", false, "Also serve a web UI to browse contexts and merge pasted kubeconfigs")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	token := *tokenFlag
+	if token == "" {
+		if quietEnabled {
+			return fmt.Errorf("quiet mode would hide the generated token, pass --token")
+		}
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("generating token: %w", err)
+		}
+		token = hex.EncodeToString(buf)
+	}
+	s := &apiServer{configPath: configPath, token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/contexts", s.auth(s.handleContexts))
+	mux.HandleFunc("GET /api/config", s.auth(s.handleConfig))
+	mux.HandleFunc("POST /api/merge", s.auth(s.handleMerge))
+	mux.HandleFunc("POST /api/prune", s.auth(s.handlePrune))
+
+	if *uiFlag {
+		mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(webUI)
+		})
+	}
+
+	audit.source = "serve"
+	infof("Serving %s on http://%s\nAuthorization: Bearer %s\n", configPath, *listenFlag, token)
+	if *uiFlag {
+		infof("Web UI: http://%s/#token=%s\n", *listenFlag, token)
+	}
+	server := &http.Server{Addr: *listenFlag, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return server.ListenAndServe()
+}
+
+// auth rejects requests without the server's bearer token.
+func (s *apiServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, fmt.Errorf("missing or wrong bearer token"))
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		debugf("%s %s", r.Method, r.URL)
+		next(w, r)
+	}
+}
+
+func (s *apiServer) handleContexts(w http.ResponseWriter, r *http.Request) {
+	_, cfg, err := loadConfig(s.configPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, listContexts(cfg))
+}
+
+// handleConfig returns the kubeconfig, or the context given as query
+// parameter, with all credentials stripped.
+func (s *apiServer) handleConfig(w http.ResponseWriter, r *http.Request) {
+	_, cfg, err := loadConfig(s.configPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if name := r.URL.Query().Get("context"); name != "" {
+		if cfg, err = exportContext(cfg, name); err != nil {
+			writeJSONError(w, http.StatusNotFound, err)
+			return
+		}
+	} else {
+		cfg = cfg.DeepCopy()
+	}
+	sanitizeConfig(cfg)
+	data, err := clientcmd.Write(*cfg)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(data)
+}
+
+// handleMerge merges the kubeconfig in the request body. With try=true the
+// changes are only reported.
+func (s *apiServer) handleMerge(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWatchedFileSize))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := kubeconfig.Validate(body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	incoming, err := clientcmd.Load(body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("parsing kubeconfig: %w", err))
+		return
+	}
+	s.modify(w, r, func(cfg *api.Config) ([]string, error) {
+		return kubeconfig.Merge(cfg, incoming, nil)
+	})
+}
+
+// handlePrune removes clusters and users no context references.
+func (s *apiServer) handlePrune(w http.ResponseWriter, r *http.Request) {
+	s.modify(w, r, func(cfg *api.Config) ([]string, error) {
+		return pruneConfig(cfg), nil
+	})
+}
+
+// modify applies change to the kubeconfig and saves it unless the request
+// has try=true, responding with the list of changes.
+func (s *apiServer) modify(w http.ResponseWriter, r *http.Request, change func(cfg *api.Config) ([]string, error)) {
+	origData, cfg, err := loadConfig(s.configPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	changes, err := change(cfg)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if changes == nil {
+		changes = []string{}
+	}
+	try := r.URL.Query().Get("try") == "true"
+	if !try && len(changes) > 0 {
+		if err := saveConfig(s.configPath, origData, cfg, changes); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	writeJSON(w, map[string]interface{}{"changes": changes, "applied": !try && len(changes) > 0})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// settings are the user defaults read from the settings file. Command-line
+// flags still take precedence.
+type settings struct {
+	// Kubeconfig is the default for --config.
+	Kubeconfig string `yaml:"kubeconfig"`
+	// BackupDir is where backups are written instead of next to the
+	// kubeconfig.
+	BackupDir string `yaml:"backup-dir"`
+	// BackupRetention is the number of backups to keep per kubeconfig, 0
+	// keeps all of them.
+	BackupRetention int `yaml:"backup-retention"`
+	// ImportSource is used by import when no source is given.
+	ImportSource string `yaml:"import-source"`
+	// MergeStrategy is the default for --strategy.
+	MergeStrategy string `yaml:"merge-strategy"`
+	// RenameSuffix is the default for --rename-suffix.
+	RenameSuffix string `yaml:"rename-suffix"`
+	// NameTemplate is the default for --name-template.
+	NameTemplate string `yaml:"name-template"`
+	// Theme is the default for --theme.
+	Theme string `yaml:"theme"`
+	// AccentColor is the default for --accent-color.
+	AccentColor string `yaml:"accent-color"`
+	// Webhook is a URL the change summary of every update is POSTed to as
+	// JSON.
+	Webhook string `yaml:"webhook"`
+	// WebhookHeaders are sent along with the webhook request, e.g. for
+	// authentication. Environment variables in values are expanded.
+	WebhookHeaders map[string]string `yaml:"webhook-headers"`
+	// Profiles are named updates started with the run command.
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+var userSettings = settings{Kubeconfig: defaultConfigPath, Theme: defaultTheme}
+
+// settingsPath returns the location of the settings file, honoring
+// XDG_CONFIG_HOME.
+func settingsPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "kubeconfig-updater", "config.yaml"), nil
+	}
+	return expandPath("~/.config/kubeconfig-updater/config.yaml")
+}
+
+// loadSettings reads the settings file into userSettings. A missing file is
+// not an error.
+func loadSettings() error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading settings: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &userSettings); err != nil {
+		return fmt.Errorf("parsing settings %s: %w", path, err)
+	}
+	if userSettings.Kubeconfig == "" {
+		userSettings.Kubeconfig = defaultConfigPath
+	}
+	if userSettings.Theme == "" {
+		userSettings.Theme = defaultTheme
+	}
+	if userSettings.MergeStrategy != "" {
+		var strategy mergeStrategy
+		if err := strategy.Set(userSettings.MergeStrategy); err != nil {
+			return fmt.Errorf("parsing settings %s: merge-strategy: %w", path, err)
+		}
+	}
+	if userSettings.BackupRetention < 0 {
+		return fmt.Errorf("parsing settings %s: backup-retention must not be negative", path)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func runEncrypt(args []string) error {
+	fs, configPathFlag := newFlagSet("encrypt")
+	ageFlag := fs.String("age", "", "Comma separated age recipients")
+	pgpFlag := fs.String("pgp", "", "Comma separated PGP fingerprints")
+	secretsOnlyFlag := fs.Bool("secrets-only", false, "Only encrypt tokens, keys and passwords, keep the rest readable")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if kubeconfig.ReadSopsMetadata(origData) != nil {
+		return fmt.Errorf("%s is already encrypted", configPath)
+	}
+
+	meta := &kubeconfig.SopsMetadata{}
+	for _, recipient := range splitList(*ageFlag) {
+		meta.Age = append(meta.Age, kubeconfig.SopsAgeKey{Recipient: recipient})
+	}
+	for _, fingerprint := range splitList(*pgpFlag) {
+		meta.PGP = append(meta.PGP, kubeconfig.SopsPGPKey{Fingerprint: fingerprint})
+	}
+	if *secretsOnlyFlag {
+		meta.EncryptedRegex = kubeconfig.SecretFieldsRegex
+	}
+
+	plain, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	outData, err := kubeconfig.EncryptSops(configPath, meta, plain)
+	if err != nil {
+		return err
+	}
+	return writeConfig(configPath, origData, outData, []string{"Encrypted kubeconfig with sops"})
+}
+
+func runView(args []string) error {
+	fs, configPathFlag := newFlagSet("view")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		cfg, err = exportContext(cfg, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("expected at most one context name, got %d", fs.NArg())
+	}
+	outData, err := kubeconfig.Marshal(cfg, outputFormat(origData))
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	_, err = os.Stdout.Write(outData)
+	return err
+}
+
+// splitList splits a comma separated list, dropping empty elements.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
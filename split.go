@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+)
+
+func runSplit(args []string) error {
+	fs, configPathFlag := newFlagSet("split")
+	forceFlag := fs.Bool("force", false, "Overwrite existing files in the target directory")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one target directory, got %d arguments", fs.NArg())
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	dir, err := expandPath(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating target directory: %w", err)
+	}
+
+	var names []string
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	format := outputFormat(origData)
+	for _, name := range names {
+		path := filepath.Join(dir, contextFileName(name, format))
+		if _, err := os.Stat(path); err == nil && !*forceFlag {
+			return fmt.Errorf("%s already exists, use --force to overwrite", path)
+		}
+		exported, err := exportContext(cfg, name)
+		if err != nil {
+			return fmt.Errorf("exporting context %s: %w", name, err)
+		}
+		outData, err := kubeconfig.Marshal(exported, format)
+		if err != nil {
+			return fmt.Errorf("marshaling context %s: %w", name, err)
+		}
+		if err := ioutil.WriteFile(path, outData, 0o600); err != nil {
+			return fmt.Errorf("writing context %s: %w", name, err)
+		}
+		infof("Wrote context %q to %s\n", name, path)
+	}
+	return nil
+}
+
+// contextFileName derives the name of a kubeconfig file in the given format
+// from a context name.
+func contextFileName(name, format string) string {
+	return safeFileName(name) + "." + format
+}
+
+// safeFileName replaces characters in name that are not safe in file names
+// on common platforms.
+func safeFileName(name string) string {
+	replacer := strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_",
+		"\"", "_", "<", "_", ">", "_", "|", "_")
+	return replacer.Replace(name)
+}
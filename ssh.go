@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// sshRun runs command on target (user@host) via the system ssh client, so
+// agent, key and ~/.ssh/config settings apply as usual.
+func sshRun(target string, port int, command string) ([]byte, error) {
+	args := []string{"-o", "BatchMode=yes"}
+	if port != 0 {
+		args = append(args, "-p", strconv.Itoa(port))
+	}
+	args = append(args, target, command)
+	return runTool("ssh", args...)
+}
+
+// sshCat returns the content of the file at path on target, using sudo if
+// it is allowed without a password, as kubeconfigs of cluster nodes are
+// often only readable by root.
+func sshCat(target string, port int, path string) ([]byte, error) {
+	quoted := shellQuote(path)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		// Leave the home directory to the remote shell
+		quoted = "~/" + shellQuote(rest)
+	}
+	return sshRun(target, port, "sudo -n cat "+quoted+" 2>/dev/null || cat "+quoted)
+}
+
+// shellQuote quotes s as a single word for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// readSSHKubeconfig fetches the kubeconfig at an scp-style location like
+// user@host:/etc/kubernetes/admin.conf and returns it with the SSH host.
+func readSSHKubeconfig(location string) ([]byte, string, error) {
+	target, path, ok := splitSSHLocation(location)
+	if !ok {
+		return nil, "", fmt.Errorf("expected user@host:/path, got %q", location)
+	}
+	data, err := sshCat(target, 0, path)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, sshHost(target), nil
+}
+
+// splitSSHLocation splits an scp-style location into the ssh target and the
+// path. IPv6 addresses may be given in brackets, user@[::1]:/path, or bare
+// if the path is absolute, in which case the last colon before the path
+// separates them. The brackets are removed from the target.
+func splitSSHLocation(location string) (string, string, bool) {
+	user, hostPath := "", location
+	if i := strings.Index(location, "@"); i >= 0 {
+		user, hostPath = location[:i+1], location[i+1:]
+	}
+	var host, path string
+	switch {
+	case strings.HasPrefix(hostPath, "["):
+		end := strings.Index(hostPath, "]:")
+		if end < 0 {
+			return "", "", false
+		}
+		host, path = hostPath[1:end], hostPath[end+2:]
+	case strings.Contains(hostPath, ":/"):
+		i := strings.Index(hostPath, ":/")
+		host, path = hostPath[:i], hostPath[i+1:]
+	default:
+		var ok bool
+		if host, path, ok = strings.Cut(hostPath, ":"); !ok {
+			return "", "", false
+		}
+	}
+	if host == "" || path == "" {
+		return "", "", false
+	}
+	return user + host, path, true
+}
+
+// sshHost returns the host part of an ssh target like user@host.
+func sshHost(target string) string {
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		return target[i+1:]
+	}
+	return target
+}
+
+// rewriteLoopback replaces loopback server addresses (127.0.0.1, localhost,
+// ::1, 0.0.0.0) in cfg with host, keeping scheme and port. Server
+// certificates of k3s and friends include the node's addresses, so the
+// cluster stays reachable with its CA. It returns a description of every
+// rewritten server.
+func rewriteLoopback(cfg *api.Config, host string) []string {
+	var changes []string
+	for name, cluster := range cfg.Clusters {
+		u, err := url.Parse(cluster.Server)
+		if err != nil {
+			continue
+		}
+		switch u.Hostname() {
+		case "127.0.0.1", "localhost", "::1", "0.0.0.0":
+		default:
+			continue
+		}
+		old := cluster.Server
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(host, port)
+		} else {
+			u.Host = host
+		}
+		cluster.Server = u.String()
+		changes = append(changes, fmt.Sprintf("Rewrote cluster %q server from %s to %s", name, old, cluster.Server))
+	}
+	return changes
+}
+
+// rewriteServers applies a sed-style substitution like s|127.0.0.1|10.0.0.5|
+// to the server URLs of cfg and returns a description of every rewritten
+// server. Any character may serve as delimiter; a trailing g replaces every
+// match instead of the first.
+func rewriteServers(cfg *api.Config, expr string) ([]string, error) {
+	if len(expr) < 2 || expr[0] != 's' {
+		return nil, fmt.Errorf("invalid server rewrite %q, expected s/pattern/replacement/", expr)
+	}
+	parts := strings.Split(expr[2:], expr[1:2])
+	if len(parts) != 3 || (parts[2] != "" && parts[2] != "g") {
+		return nil, fmt.Errorf("invalid server rewrite %q, expected s/pattern/replacement/", expr)
+	}
+	re, err := regexp.Compile(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid server rewrite %q: %w", expr, err)
+	}
+	// sed refers to groups as \1, regexp as ${1}
+	replacement := regexp.MustCompile(`\\(\d)`).ReplaceAllString(parts[1], `$${$1}`)
+	return replaceServers(cfg, re, replacement, parts[2] == "g"), nil
+}
+
+// replaceServers replaces the first match of re, or every match if global
+// is set, in the server URLs of cfg with replacement, which may refer to
+// groups as ${1}. It returns a description of every rewritten server.
+func replaceServers(cfg *api.Config, re *regexp.Regexp, replacement string, global bool) []string {
+	var changes []string
+	for _, name := range sortedNames(cfg.Clusters) {
+		cluster := cfg.Clusters[name]
+		old := cluster.Server
+		if global {
+			cluster.Server = re.ReplaceAllString(old, replacement)
+		} else if loc := re.FindStringSubmatchIndex(old); loc != nil {
+			cluster.Server = old[:loc[0]] + string(re.ExpandString(nil, replacement, old, loc)) + old[loc[1]:]
+		}
+		if cluster.Server != old {
+			changes = append(changes, fmt.Sprintf("Rewrote cluster %q server from %s to %s", name, old, cluster.Server))
+		}
+	}
+	return changes
+}
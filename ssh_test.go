@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestSplitSSHLocation(t *testing.T) {
+	tests := []struct {
+		location   string
+		wantTarget string
+		wantPath   string
+		wantOK     bool
+	}{
+		{"admin@cp1:/etc/kubernetes/admin.conf", "admin@cp1", "/etc/kubernetes/admin.conf", true},
+		{"cp1:admin.conf", "cp1", "admin.conf", true},
+		{"root@[fd00::10]:/etc/rancher/k3s/k3s.yaml", "root@fd00::10", "/etc/rancher/k3s/k3s.yaml", true},
+		{"[::1]:config", "::1", "config", true},
+		{"root@fd00::10:/etc/rancher/k3s/k3s.yaml", "root@fd00::10", "/etc/rancher/k3s/k3s.yaml", true},
+		{"cp1:/path/with:colon", "cp1", "/path/with:colon", true},
+		{"cp1", "", "", false},
+		{"cp1:", "", "", false},
+		{":/etc/config", "", "", false},
+		{"[::1]/config", "", "", false},
+	}
+	for _, tt := range tests {
+		target, path, ok := splitSSHLocation(tt.location)
+		if target != tt.wantTarget || path != tt.wantPath || ok != tt.wantOK {
+			t.Errorf("splitSSHLocation(%q) = %q, %q, %t, want %q, %q, %t",
+				tt.location, target, path, ok, tt.wantTarget, tt.wantPath, tt.wantOK)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"/etc/kubernetes/admin.conf": `'/etc/kubernetes/admin.conf'`,
+		"/tmp/my config":             `'/tmp/my config'`,
+		"/tmp/x; rm -rf ~":           `'/tmp/x; rm -rf ~'`,
+		"/tmp/$(id)`id`":             "'/tmp/$(id)`id`'",
+		"/tmp/it's":                  `'/tmp/it'\''s'`,
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func runSwitch(args []string) error {
+	fs, configPathFlag := newFlagSet("switch")
+	tagFlag := addTagFlag(fs, "Only offer contexts with this tag")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Contexts) == 0 {
+		return fmt.Errorf("kubeconfig has no contexts")
+	}
+
+	var selected string
+	switch fs.NArg() {
+	case 0:
+		candidates := cfg.DeepCopy()
+		filterContexts(candidates, *tagFlag)
+		if len(candidates.Contexts) == 0 {
+			return fmt.Errorf("no context has the tags %s", tagFlag)
+		}
+		selected = cfg.CurrentContext
+		err = runForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title(filterTitle("Switch to context")).
+					Options(contextOptions(candidates)...).
+					Filtering(true).
+					Height(15).
+					Value(&selected),
+			),
+		)
+		if err != nil {
+			return fmt.Errorf("selecting context: %w", err)
+		}
+	case 1:
+		selected = fs.Arg(0)
+		if _, exists := cfg.Contexts[selected]; !exists {
+			return fmt.Errorf("context %s not found", selected)
+		}
+	default:
+		return fmt.Errorf("expected at most one context name, got %d", fs.NArg())
+	}
+
+	if selected == cfg.CurrentContext {
+		infof("Already using context %q\n", selected)
+		return nil
+	}
+	changes := []string{fmt.Sprintf("Updated current-context from %q to %q", cfg.CurrentContext, selected)}
+	cfg.CurrentContext = selected
+	if err := saveConfig(configPath, origData, cfg, changes); err != nil {
+		return err
+	}
+	infof("Switched to context %q\n", selected)
+	return nil
+}
+
+// offerCurrentContext points current-context at one of the contexts just
+// added or updated: right away with setCurrent and a single context,
+// otherwise after asking if a terminal is available. It returns the change
+// made, if any.
+func offerCurrentContext(cfg *api.Config, touched []string, setCurrent bool) (string, error) {
+	if len(touched) == 0 || (len(touched) == 1 && touched[0] == cfg.CurrentContext) {
+		return "", nil
+	}
+	selected := ""
+	switch {
+	case setCurrent && len(touched) == 1:
+		selected = touched[0]
+	case !canPrompt():
+		if setCurrent {
+			return "", fmt.Errorf("--set-current is ambiguous, %d contexts were touched: %s", len(touched), quoteList(touched))
+		}
+		return "", nil
+	case len(touched) == 1:
+		confirmed := true
+		description := "No current context is set."
+		if cfg.CurrentContext != "" {
+			description = fmt.Sprintf("Currently %q.", cfg.CurrentContext)
+		}
+		err := runForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Switch current-context to %s?", touched[0])).
+					Description(description).
+					Value(&confirmed),
+			),
+		)
+		if err != nil {
+			return "", fmt.Errorf("asking to switch context: %w", err)
+		}
+		if confirmed {
+			selected = touched[0]
+		}
+	default:
+		options := []huh.Option[string]{huh.NewOption(fmt.Sprintf("Keep current context %q", cfg.CurrentContext), "")}
+		for _, name := range touched {
+			options = append(options, huh.NewOption(name, name))
+		}
+		err := runForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title("Switch current-context to one of the imported contexts?").
+					Options(options...).
+					Value(&selected),
+			),
+		)
+		if err != nil {
+			return "", fmt.Errorf("asking to switch context: %w", err)
+		}
+	}
+	if selected == "" || selected == cfg.CurrentContext {
+		return "", nil
+	}
+	change := fmt.Sprintf("Updated current-context from %q to %q", cfg.CurrentContext, selected)
+	cfg.CurrentContext = selected
+	return change, nil
+}
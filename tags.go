@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// tagSelectors is a repeatable flag.Value collecting tag selectors.
+type tagSelectors []string
+
+func (t *tagSelectors) String() string { return strings.Join(*t, ",") }
+
+func (t *tagSelectors) Set(value string) error {
+	for _, selector := range strings.Split(value, ",") {
+		if key, _ := kubeconfig.ParseTag(selector); key == "" {
+			return fmt.Errorf("invalid tag selector %q, expected key or key=value", selector)
+		}
+		*t = append(*t, selector)
+	}
+	return nil
+}
+
+// addTagFlag registers the repeatable --tag flag on fs.
+func addTagFlag(fs *flag.FlagSet, usage string) *tagSelectors {
+	var selectors tagSelectors
+	fs.Var(&selectors, "tag", usage+", as key=value or key; repeatable")
+	return &selectors
+}
+
+// contextMatches reports whether the named context of cfg carries all tags
+// selected by selectors. Contexts with unreadable tags never match.
+func contextMatches(cfg *api.Config, name string, selectors tagSelectors) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+	tags, err := kubeconfig.GetTags(cfg.Contexts[name])
+	if err != nil {
+		debugf("context %q: %v", name, err)
+		return false
+	}
+	return kubeconfig.MatchTags(tags, selectors)
+}
+
+// filterContexts removes the contexts from cfg that do not match selectors.
+func filterContexts(cfg *api.Config, selectors tagSelectors) {
+	for name := range cfg.Contexts {
+		if !contextMatches(cfg, name, selectors) {
+			delete(cfg.Contexts, name)
+		}
+	}
+}
+
+func runTag(args []string) error {
+	fs, configPathFlag := newFlagSet("tag")
+	removeFlag := fs.Bool("remove", false, "Remove the given tags instead of setting them")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("expected a context name and tags")
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	ctx, exists := cfg.Contexts[name]
+	if !exists {
+		return fmt.Errorf("context %s not found", name)
+	}
+	tags, err := kubeconfig.GetTags(ctx)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		fmt.Println(kubeconfig.FormatTags(tags))
+		return nil
+	}
+
+	var changes []string
+	for _, tag := range fs.Args()[1:] {
+		key, value := kubeconfig.ParseTag(tag)
+		if key == "" {
+			return fmt.Errorf("invalid tag %q, expected key=value or key", tag)
+		}
+		old, had := tags[key]
+		switch {
+		case *removeFlag && had:
+			delete(tags, key)
+			changes = append(changes, fmt.Sprintf("Removed tag %s from context %q", key, name))
+		case !*removeFlag && (!had || old != value):
+			tags[key] = value
+			changes = append(changes, fmt.Sprintf("Tagged context %q with %s", name, kubeconfig.FormatTags(map[string]string{key: value})))
+		}
+	}
+	if len(changes) == 0 {
+		infof("No changes made.\n")
+		return nil
+	}
+	if err := kubeconfig.SetTags(ctx, tags); err != nil {
+		return err
+	}
+	printChanges(changes)
+	return saveConfig(configPath, origData, cfg, changes)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"golang.org/x/term"
+)
+
+var (
+	plainFlag       bool
+	themeFlag       string
+	accentColorFlag string
+)
+
+var errNoTerminal = errors.New("stdin is not a terminal, pass the required values as flags")
+
+// addUIFlags registers the --plain, --theme and --accent-color flags on fs.
+func addUIFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&plainFlag, "plain", false, "Use line-based prompts instead of interactive widgets")
+	fs.StringVar(&themeFlag, "theme", userSettings.Theme, "Prompt theme: "+strings.Join(themeNames(), ", "))
+	fs.StringVar(&accentColorFlag, "accent-color", userSettings.AccentColor, "Accent color of prompts, as ANSI number or #rrggbb")
+}
+
+// canPrompt reports whether stdin is a terminal the user can answer prompts on.
+func canPrompt() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// plainMode reports whether prompts have to be line-based: with --plain,
+// NO_COLOR or TERM=dumb, and whenever stdin or stdout is not a terminal.
+func plainMode() bool {
+	return plainFlag || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" ||
+		!canPrompt() || !term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// runForm runs a form made of groups, using huh's accessible line-based
+// prompts in plain mode. Without a terminal on stdin it fails right away
+// instead of waiting for input that never comes.
+func runForm(groups ...*huh.Group) error {
+	if !canPrompt() {
+		return errNoTerminal
+	}
+	theme, err := formTheme()
+	if err != nil {
+		return err
+	}
+	return huh.NewForm(groups...).WithTheme(theme).WithAccessible(plainMode()).Run()
+}
+
+// filterTitle appends a filtering hint to the title of a filterable select,
+// unless plain mode shows a numbered list instead.
+func filterTitle(title string) string {
+	if plainMode() {
+		return title
+	}
+	return title + " (type to filter)"
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const defaultTheme = "charm"
+
+// themes are the prompt themes selectable with --theme.
+var themes = map[string]func() *huh.Theme{
+	"charm":         huh.ThemeCharm,
+	"dracula":       huh.ThemeDracula,
+	"catppuccin":    huh.ThemeCatppuccin,
+	"base16":        huh.ThemeBase16,
+	"base":          huh.ThemeBase,
+	"high-contrast": themeHighContrast,
+}
+
+// themeNames returns the names of all themes, sorted.
+func themeNames() []string {
+	var names []string
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formTheme returns the theme selected with --theme, with the accent color
+// from --accent-color applied.
+func formTheme() (*huh.Theme, error) {
+	newTheme, exists := themes[themeFlag]
+	if !exists {
+		return nil, fmt.Errorf("unknown theme %q, expected one of %v", themeFlag, themeNames())
+	}
+	t := newTheme()
+	if accentColorFlag == "" {
+		return t, nil
+	}
+	accent := lipgloss.Color(accentColorFlag)
+	for _, styles := range []*huh.FieldStyles{&t.Focused, &t.Blurred} {
+		styles.SelectSelector = styles.SelectSelector.Foreground(accent)
+		styles.MultiSelectSelector = styles.MultiSelectSelector.Foreground(accent)
+		styles.NextIndicator = styles.NextIndicator.Foreground(accent)
+		styles.PrevIndicator = styles.PrevIndicator.Foreground(accent)
+		styles.TextInput.Prompt = styles.TextInput.Prompt.Foreground(accent)
+	}
+	t.Focused.Base = t.Focused.Base.BorderForeground(accent)
+	t.Focused.Title = t.Focused.Title.Foreground(accent)
+	t.Focused.NoteTitle = t.Focused.NoteTitle.Foreground(accent)
+	t.Focused.FocusedButton = t.Focused.FocusedButton.Background(accent)
+	return t, nil
+}
+
+// themeHighContrast returns a theme using only bold text and the bright
+// ANSI colors, without dimmed descriptions or placeholders.
+func themeHighContrast() *huh.Theme {
+	t := huh.ThemeBase()
+	white, black, yellow := lipgloss.Color("15"), lipgloss.Color("0"), lipgloss.Color("11")
+
+	t.Focused.Base = t.Focused.Base.BorderForeground(yellow)
+	t.Focused.Title = t.Focused.Title.Foreground(white).Bold(true)
+	t.Focused.NoteTitle = t.Focused.NoteTitle.Foreground(white).Bold(true)
+	t.Focused.Description = t.Focused.Description.Foreground(white)
+	t.Focused.ErrorIndicator = t.Focused.ErrorIndicator.Foreground(lipgloss.Color("9")).Bold(true)
+	t.Focused.ErrorMessage = t.Focused.ErrorMessage.Foreground(lipgloss.Color("9")).Bold(true)
+	t.Focused.SelectSelector = t.Focused.SelectSelector.Foreground(yellow).Bold(true)
+	t.Focused.NextIndicator = t.Focused.NextIndicator.Foreground(yellow).Bold(true)
+	t.Focused.PrevIndicator = t.Focused.PrevIndicator.Foreground(yellow).Bold(true)
+	t.Focused.Option = t.Focused.Option.Foreground(white)
+	t.Focused.MultiSelectSelector = t.Focused.MultiSelectSelector.Foreground(yellow).Bold(true)
+	t.Focused.SelectedOption = t.Focused.SelectedOption.Foreground(yellow).Bold(true)
+	t.Focused.SelectedPrefix = t.Focused.SelectedPrefix.Foreground(yellow).Bold(true)
+	t.Focused.UnselectedOption = t.Focused.UnselectedOption.Foreground(white)
+	t.Focused.FocusedButton = t.Focused.FocusedButton.Foreground(black).Background(yellow).Bold(true)
+	t.Focused.BlurredButton = t.Focused.BlurredButton.Foreground(white).Background(black)
+	t.Focused.TextInput.Prompt = t.Focused.TextInput.Prompt.Foreground(yellow)
+	t.Focused.TextInput.Text = t.Focused.TextInput.Text.Foreground(white)
+	t.Focused.TextInput.Placeholder = t.Focused.TextInput.Placeholder.Foreground(white)
+
+	t.Blurred = t.Focused
+	t.Blurred.Base = t.Blurred.Base.BorderStyle(lipgloss.HiddenBorder())
+	t.Blurred.NextIndicator = lipgloss.NewStyle()
+	t.Blurred.PrevIndicator = lipgloss.NewStyle()
+
+	t.Help.ShortKey = t.Help.ShortKey.Foreground(white).Bold(true)
+	t.Help.ShortDesc = t.Help.ShortDesc.Foreground(white)
+	t.Help.ShortSeparator = t.Help.ShortSeparator.Foreground(white)
+	t.Help.FullKey = t.Help.FullKey.Foreground(white).Bold(true)
+	t.Help.FullDesc = t.Help.FullDesc.Foreground(white)
+	t.Help.FullSeparator = t.Help.FullSeparator.Foreground(white)
+	return t
+}
+
+// applyTUITheme styles the full-screen views to match --theme and
+// --accent-color.
+func applyTUITheme() error {
+	if _, exists := themes[themeFlag]; !exists {
+		return fmt.Errorf("unknown theme %q, expected one of %v", themeFlag, themeNames())
+	}
+	if themeFlag == "high-contrast" {
+		activePaneStyle = paneStyle.BorderForeground(lipgloss.Color("11"))
+		titleStyle = titleStyle.Foreground(lipgloss.Color("15"))
+		helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("15"))
+		invalidStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("15"))
+		validStyle = validStyle.Foreground(lipgloss.Color("10")).Bold(true)
+	}
+	if accentColorFlag != "" {
+		activePaneStyle = paneStyle.BorderForeground(lipgloss.Color(accentColorFlag))
+		titleStyle = titleStyle.Foreground(lipgloss.Color(accentColorFlag))
+	}
+	return nil
+}
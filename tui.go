@@ -0,0 +1,405 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	paneContexts = iota
+	paneClusters
+	paneUsers
+	paneCount
+)
+
+var paneTitles = [paneCount]string{"Contexts", "Clusters", "Users"}
+
+// editableFields lists the fields that can be edited inline per pane, in
+// the order of their number keys.
+var editableFields = [paneCount][]string{
+	paneContexts: {"cluster", "user", "namespace"},
+	paneClusters: {"server"},
+	paneUsers:    {"token", "as", "as-groups", "as-user-extra"},
+}
+
+var (
+	paneStyle       = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	activePaneStyle = paneStyle.BorderForeground(lipgloss.Color("63"))
+	selectedStyle   = lipgloss.NewStyle().Reverse(true)
+	titleStyle      = lipgloss.NewStyle().Bold(true)
+	helpStyle       = lipgloss.NewStyle().Faint(true)
+)
+
+// tuiModel is the bubbletea model of the full-screen browser. Edits are
+// applied to cfg right away and described in changes; the file is only
+// written after leaving the TUI.
+type tuiModel struct {
+	cfg     *api.Config
+	pane    int
+	names   [paneCount][]string
+	cursor  [paneCount]int
+	changes []string
+	status  string
+	width   int
+	height  int
+	save    bool
+
+	editing   string
+	editInput textinput.Model
+}
+
+func runTUI(args []string) error {
+	fs, configPathFlag := newFlagSet("tui")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if plainMode() {
+		return fmt.Errorf("tui needs an interactive terminal, see list, switch and set-namespace instead")
+	}
+
+	if err := applyTUITheme(); err != nil {
+		return err
+	}
+
+	m := &tuiModel{cfg: cfg}
+	m.refreshNames()
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		return err
+	}
+	if !m.save {
+		if len(m.changes) > 0 {
+			infof("Quit without saving, kubeconfig not modified.\n")
+		}
+		return nil
+	}
+	printChanges(m.changes)
+	if len(m.changes) == 0 {
+		return nil
+	}
+	return saveConfig(configPath, origData, cfg, m.changes)
+}
+
+func (m *tuiModel) refreshNames() {
+	m.names[paneContexts] = sortedNames(m.cfg.Contexts)
+	m.names[paneClusters] = sortedNames(m.cfg.Clusters)
+	m.names[paneUsers] = sortedNames(m.cfg.AuthInfos)
+	for pane := range m.names {
+		if m.cursor[pane] >= len(m.names[pane]) {
+			m.cursor[pane] = max(len(m.names[pane])-1, 0)
+		}
+	}
+}
+
+// selected returns the name of the entry under the cursor of the active
+// pane, or an empty string if the pane is empty.
+func (m *tuiModel) selected() string {
+	names := m.names[m.pane]
+	if len(names) == 0 {
+		return ""
+	}
+	return names[m.cursor[m.pane]]
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		if m.editing != "" {
+			return m.updateEditing(msg)
+		}
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "w", "ctrl+s":
+			m.save = true
+			return m, tea.Quit
+		case "tab", "right", "l":
+			m.pane = (m.pane + 1) % paneCount
+		case "shift+tab", "left", "h":
+			m.pane = (m.pane + paneCount - 1) % paneCount
+		case "down", "j":
+			if m.cursor[m.pane] < len(m.names[m.pane])-1 {
+				m.cursor[m.pane]++
+			}
+		case "up", "k":
+			if m.cursor[m.pane] > 0 {
+				m.cursor[m.pane]--
+			}
+		default:
+			if field, ok := editableField(m.pane, msg.String()); ok && m.selected() != "" {
+				m.startEditing(field)
+				return m, textinput.Blink
+			}
+		}
+	}
+	return m, nil
+}
+
+// editableField returns the editable field of pane whose number key is key.
+func editableField(pane int, key string) (string, bool) {
+	fields := editableFields[pane]
+	if len(key) != 1 || key[0] < '1' || int(key[0]-'1') >= len(fields) {
+		return "", false
+	}
+	return fields[key[0]-'1'], true
+}
+
+func (m *tuiModel) startEditing(field string) {
+	m.editing = field
+	m.editInput = textinput.New()
+	m.editInput.Prompt = field + ": "
+	m.editInput.SetValue(m.fieldValue(field))
+	m.editInput.Focus()
+	m.status = ""
+}
+
+func (m *tuiModel) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editing = ""
+		return m, nil
+	case "enter":
+		if err := m.setField(m.editing, strings.TrimSpace(m.editInput.Value())); err != nil {
+			m.status = "Error: " + err.Error()
+		}
+		m.editing = ""
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.editInput, cmd = m.editInput.Update(msg)
+	return m, cmd
+}
+
+// fieldValue returns the current value of an editable field of the
+// selected entry.
+func (m *tuiModel) fieldValue(field string) string {
+	name := m.selected()
+	switch m.pane {
+	case paneContexts:
+		ctx := m.cfg.Contexts[name]
+		switch field {
+		case "cluster":
+			return ctx.Cluster
+		case "user":
+			return ctx.AuthInfo
+		case "namespace":
+			return ctx.Namespace
+		}
+	case paneClusters:
+		return m.cfg.Clusters[name].Server
+	case paneUsers:
+		user := m.cfg.AuthInfos[name]
+		switch field {
+		case "token":
+			return user.Token
+		case "as":
+			return user.Impersonate
+		case "as-groups":
+			return strings.Join(user.ImpersonateGroups, ",")
+		case "as-user-extra":
+			return kubeconfig.FormatExtra(user.ImpersonateUserExtra)
+		}
+	}
+	return ""
+}
+
+// setField validates and applies a new value for an editable field of the
+// selected entry, recording the change.
+func (m *tuiModel) setField(field, value string) error {
+	if value == m.fieldValue(field) {
+		return nil
+	}
+	name := m.selected()
+	var change string
+	switch m.pane {
+	case paneContexts:
+		ctx := m.cfg.Contexts[name]
+		switch field {
+		case "cluster":
+			if _, exists := m.cfg.Clusters[value]; !exists {
+				return fmt.Errorf("cluster %s not found", value)
+			}
+			change = fmt.Sprintf("Updated context %q cluster from %q to %q", name, ctx.Cluster, value)
+			ctx.Cluster = value
+		case "user":
+			if _, exists := m.cfg.AuthInfos[value]; !exists {
+				return fmt.Errorf("user %s not found", value)
+			}
+			change = fmt.Sprintf("Updated context %q user from %q to %q", name, ctx.AuthInfo, value)
+			ctx.AuthInfo = value
+		case "namespace":
+			if err := validateNamespace(value); err != nil {
+				return err
+			}
+			change = kubeconfig.SetNamespace(name, ctx, value)
+		}
+	case paneClusters:
+		cluster := m.cfg.Clusters[name]
+		change = fmt.Sprintf("Updated cluster %q server from %s to %s", name, cluster.Server, value)
+		cluster.Server = value
+	case paneUsers:
+		user := m.cfg.AuthInfos[name]
+		if field == "token" {
+			change = fmt.Sprintf("Updated user %q token from %s to %s",
+				name, kubeconfig.DescribeToken(user.Token), kubeconfig.DescribeToken(value))
+			user.Token = value
+			break
+		}
+		imp := kubeconfig.GetImpersonation(user)
+		switch field {
+		case "as":
+			imp.User = value
+		case "as-groups":
+			imp.Groups = nil
+			for _, group := range strings.Split(value, ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					imp.Groups = append(imp.Groups, group)
+				}
+			}
+		case "as-user-extra":
+			extra, err := kubeconfig.ParseExtra(value)
+			if err != nil {
+				return err
+			}
+			imp.Extra = extra
+		}
+		change = fmt.Sprintf("Updated user %q impersonation from %s to %s", name, kubeconfig.GetImpersonation(user), imp)
+		kubeconfig.SetImpersonation(user, imp)
+	}
+	m.changes = append(m.changes, change)
+	m.status = change
+	return nil
+}
+
+func (m *tuiModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	colWidth := max(m.width/paneCount-4, 10)
+	listHeight := max(m.height/2-4, 3)
+
+	var columns []string
+	for pane := 0; pane < paneCount; pane++ {
+		style := paneStyle
+		if pane == m.pane {
+			style = activePaneStyle
+		}
+		columns = append(columns, style.Width(colWidth).Height(listHeight).Render(m.renderList(pane, colWidth, listHeight)))
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+	b.WriteString("\n")
+	b.WriteString(paneStyle.Width(m.width - 4).Render(m.renderDetails()))
+	b.WriteString("\n")
+	switch {
+	case m.editing != "":
+		b.WriteString(m.editInput.View() + "\n")
+		b.WriteString(helpStyle.Render("enter apply • esc cancel"))
+	default:
+		if m.status != "" {
+			b.WriteString(m.status + "\n")
+		}
+		b.WriteString(helpStyle.Render(fmt.Sprintf("tab switch pane • ↑/↓ move • number edit field • w write and quit • q quit (%d unsaved changes)", len(m.changes))))
+	}
+	return b.String()
+}
+
+// renderList renders the names of a pane, scrolled so the cursor stays
+// visible.
+func (m *tuiModel) renderList(pane, width, height int) string {
+	lines := []string{titleStyle.Render(paneTitles[pane])}
+	names := m.names[pane]
+	start := 0
+	if m.cursor[pane] >= height-1 {
+		start = m.cursor[pane] - height + 2
+	}
+	for i := start; i < len(names) && len(lines) < height; i++ {
+		label := names[i]
+		if pane == paneContexts && label == m.cfg.CurrentContext {
+			label = "* " + label
+		}
+		label = ansi.Truncate(label, width, "…")
+		if i == m.cursor[pane] {
+			label = selectedStyle.Render(label)
+		}
+		lines = append(lines, label)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDetails describes the selected entry of the active pane, with
+// number keys in front of the editable fields.
+func (m *tuiModel) renderDetails() string {
+	name := m.selected()
+	if name == "" {
+		return "Nothing selected"
+	}
+	var lines []string
+	field := func(key, value string) {
+		for i, editable := range editableFields[m.pane] {
+			if editable == key {
+				key = fmt.Sprintf("[%d] %s", i+1, key)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%-16s %s", key, value))
+	}
+	switch m.pane {
+	case paneContexts:
+		ctx := m.cfg.Contexts[name]
+		lines = append(lines, titleStyle.Render("Context "+name))
+		field("cluster", ctx.Cluster)
+		field("user", ctx.AuthInfo)
+		field("namespace", ctx.Namespace)
+		field("current", fmt.Sprint(name == m.cfg.CurrentContext))
+	case paneClusters:
+		cluster := m.cfg.Clusters[name]
+		lines = append(lines, titleStyle.Render("Cluster "+name))
+		field("server", cluster.Server)
+		field("CA data", kubeconfig.ShortenBytes(cluster.CertificateAuthorityData)+kubeconfig.CertSummary(cluster.CertificateAuthorityData))
+		if cluster.CertificateAuthority != "" {
+			field("CA file", cluster.CertificateAuthority)
+		}
+		field("used by", quoteList(contextsUsingCluster(m.cfg, name)))
+	case paneUsers:
+		user := m.cfg.AuthInfos[name]
+		lines = append(lines, titleStyle.Render("User "+name))
+		field("auth", authType(user))
+		field("token", kubeconfig.DescribeToken(user.Token))
+		if len(user.ClientCertificateData) > 0 {
+			field("client cert", kubeconfig.ShortenBytes(user.ClientCertificateData)+kubeconfig.CertSummary(user.ClientCertificateData))
+		}
+		if user.Exec != nil {
+			field("exec", kubeconfig.DescribeExec(user.Exec))
+		}
+		field("as", m.fieldValue("as"))
+		if user.ImpersonateUID != "" {
+			field("as-uid", user.ImpersonateUID)
+		}
+		field("as-groups", m.fieldValue("as-groups"))
+		field("as-user-extra", m.fieldValue("as-user-extra"))
+		field("used by", quoteList(contextsUsingUser(m.cfg, name)))
+	}
+	return strings.Join(lines, "\n")
+}
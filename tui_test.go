@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestEditableField(t *testing.T) {
+	tests := []struct {
+		pane  int
+		key   string
+		field string
+	}{
+		{paneContexts, "1", "cluster"},
+		{paneContexts, "3", "namespace"},
+		{paneContexts, "4", ""},
+		{paneClusters, "1", "server"},
+		{paneClusters, "2", ""},
+		{paneUsers, "1", "token"},
+		{paneUsers, "2", "as"},
+		{paneUsers, "3", "as-groups"},
+		{paneUsers, "4", "as-user-extra"},
+		{paneUsers, "5", ""},
+		{paneUsers, "0", ""},
+		{paneUsers, "q", ""},
+		{paneUsers, "11", ""},
+	}
+	for _, tt := range tests {
+		field, ok := editableField(tt.pane, tt.key)
+		if field != tt.field || ok != (tt.field != "") {
+			t.Errorf("editableField(%s, %q) = %q, %t, want %q", paneTitles[tt.pane], tt.key, field, ok, tt.field)
+		}
+	}
+}
+
+func TestRenderListTruncatesByWidth(t *testing.T) {
+	m := &tuiModel{cfg: api.NewConfig()}
+	m.names[paneContexts] = []string{"prod", "продакшн-кластер", "日本語クラスター"}
+	m.cursor[paneContexts] = 1
+	lines := strings.Split(m.renderList(paneContexts, 8, 10), "\n")[1:]
+	for _, line := range lines {
+		if !utf8.ValidString(line) {
+			t.Errorf("line %q is not valid UTF-8", line)
+		}
+		if width := ansi.StringWidth(line); width > 8 {
+			t.Errorf("line %q is %d cells wide, want at most 8", line, width)
+		}
+	}
+	if got := ansi.Strip(lines[1]); got != "продакш…" {
+		t.Errorf("truncated label = %q", got)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// certFile is a credential file extracted from a kubeconfig.
+type certFile struct {
+	path string
+	data []byte
+}
+
+func runUnflatten(args []string) error {
+	fs, configPathFlag := newFlagSet("unflatten")
+	tryFlag := fs.Bool("try", false, "Try mode: do not write any files, just print what would be extracted")
+	dirFlag := fs.String("dir", "~/.kube/certs", "Directory to extract certificates and keys into")
+	fs.Parse(args)
+
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	dir, err := expandPath(*dirFlag)
+	if err != nil {
+		return err
+	}
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	files, changes := unflattenConfig(cfg, dir)
+	printChanges(changes)
+	if *tryFlag {
+		infof("\nTry mode: no files written.\n")
+		return nil
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+			return fmt.Errorf("creating certificate directory: %w", err)
+		}
+		if err := ioutil.WriteFile(f.path, f.data, 0o600); err != nil {
+			return fmt.Errorf("writing %s: %w", f.path, err)
+		}
+	}
+	return saveConfig(configPath, origData, cfg, changes)
+}
+
+// unflattenConfig moves embedded CA, client certificate and client key data
+// out of cfg into files below dir and points the entries at those files.
+// Files are grouped by cluster: a cluster's CA ends up in <dir>/<cluster>/ca.crt
+// and the credentials of a user in <dir>/<cluster>/<user>.crt and .key, using
+// the cluster of the first context referencing the user. The files are not
+// written; they are returned for the caller to persist.
+func unflattenConfig(cfg *api.Config, dir string) ([]certFile, []string) {
+	var files []certFile
+	var changes []string
+
+	userDirs := map[string]string{}
+	var contextNames []string
+	for name := range cfg.Contexts {
+		contextNames = append(contextNames, name)
+	}
+	sort.Strings(contextNames)
+	for _, name := range contextNames {
+		ctx := cfg.Contexts[name]
+		if _, seen := userDirs[ctx.AuthInfo]; !seen && ctx.Cluster != "" {
+			userDirs[ctx.AuthInfo] = filepath.Join(dir, safeFileName(ctx.Cluster))
+		}
+	}
+
+	var clusterNames []string
+	for name := range cfg.Clusters {
+		clusterNames = append(clusterNames, name)
+	}
+	sort.Strings(clusterNames)
+	for _, name := range clusterNames {
+		cluster := cfg.Clusters[name]
+		if len(cluster.CertificateAuthorityData) == 0 {
+			continue
+		}
+		path := filepath.Join(dir, safeFileName(name), "ca.crt")
+		files = append(files, certFile{path: path, data: cluster.CertificateAuthorityData})
+		cluster.CertificateAuthorityData = nil
+		cluster.CertificateAuthority = path
+		changes = append(changes, fmt.Sprintf("Extracted cluster %q CA to %s", name, path))
+	}
+
+	var userNames []string
+	for name := range cfg.AuthInfos {
+		userNames = append(userNames, name)
+	}
+	sort.Strings(userNames)
+	for _, name := range userNames {
+		user := cfg.AuthInfos[name]
+		userDir, exists := userDirs[name]
+		if !exists {
+			userDir = filepath.Join(dir, "users")
+		}
+		if len(user.ClientCertificateData) > 0 {
+			path := filepath.Join(userDir, safeFileName(name)+".crt")
+			files = append(files, certFile{path: path, data: user.ClientCertificateData})
+			user.ClientCertificateData = nil
+			user.ClientCertificate = path
+			changes = append(changes, fmt.Sprintf("Extracted user %q client cert to %s", name, path))
+		}
+		if len(user.ClientKeyData) > 0 {
+			path := filepath.Join(userDir, safeFileName(name)+".key")
+			files = append(files, certFile{path: path, data: user.ClientKeyData})
+			user.ClientKeyData = nil
+			user.ClientKey = path
+			changes = append(changes, fmt.Sprintf("Extracted user %q client key to %s", name, path))
+		}
+	}
+	return files, changes
+}
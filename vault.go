@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// readVaultKubeconfig reads a KV secret from Vault using the standard
+// VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE environment variables. If the
+// secret has the given field, its content is returned as kubeconfig.
+// Otherwise a kubeconfig is built from the server, token and ca fields, with
+// the cluster and user named clusterName and userName.
+func readVaultKubeconfig(path, field, clusterName, userName string) ([]byte, error) {
+	secret, err := readVaultSecret(path)
+	if err != nil {
+		return nil, err
+	}
+	if kubeconfig, ok := secret[field]; ok {
+		return []byte(kubeconfig), nil
+	}
+
+	server, token := secret["server"], secret["token"]
+	if server == "" || token == "" {
+		return nil, fmt.Errorf("secret %s has neither a %q field nor server and token fields", path, field)
+	}
+	cluster := api.NewCluster()
+	cluster.Server = server
+	if ca := secret["ca"]; ca != "" {
+		// Accept both PEM and base64 encoded PEM, as found in kubeconfigs
+		if strings.HasPrefix(strings.TrimSpace(ca), "-----BEGIN") {
+			cluster.CertificateAuthorityData = []byte(ca)
+		} else if cluster.CertificateAuthorityData, err = base64.StdEncoding.DecodeString(ca); err != nil {
+			return nil, fmt.Errorf("decoding ca field of %s: %w", path, err)
+		}
+	}
+	user := api.NewAuthInfo()
+	user.Token = token
+
+	cfg := api.NewConfig()
+	cfg.Clusters[clusterName] = cluster
+	cfg.AuthInfos[userName] = user
+	cfg.Contexts[clusterName] = &api.Context{Cluster: clusterName, AuthInfo: userName}
+	return clientcmd.Write(*cfg)
+}
+
+// readVaultSecret reads the string fields of a KV secret. KV version 2 is
+// tried first, falling back to version 1.
+func readVaultSecret(path string) (map[string]string, error) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return nil, fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+	header := http.Header{"X-Vault-Token": {token}}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		header.Set("X-Vault-Namespace", namespace)
+	}
+	addr = strings.TrimSuffix(addr, "/")
+	path = strings.Trim(path, "/")
+
+	mount, rest, _ := strings.Cut(path, "/")
+	body, err := httpGet(fmt.Sprintf("%s/v1/%s/data/%s", addr, mount, rest), header)
+	if err == nil {
+		var v2 struct {
+			Data struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &v2); err != nil {
+			return nil, fmt.Errorf("parsing Vault response: %w", err)
+		}
+		return stringFields(v2.Data.Data), nil
+	}
+
+	body, err = httpGet(fmt.Sprintf("%s/v1/%s", addr, path), header)
+	if err != nil {
+		return nil, fmt.Errorf("reading secret %s: %w", path, err)
+	}
+	var v1 struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &v1); err != nil {
+		return nil, fmt.Errorf("parsing Vault response: %w", err)
+	}
+	return stringFields(v1.Data), nil
+}
+
+// stringFields returns the string values of m.
+func stringFields(m map[string]interface{}) map[string]string {
+	fields := map[string]string{}
+	for key, value := range m {
+		if s, ok := value.(string); ok {
+			fields[key] = s
+		}
+	}
+	return fields
+}
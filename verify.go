@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const verifyTimeout = 10 * time.Second
+
+// restConfigFor builds a client configuration for the named context of cfg.
+func restConfigFor(cfg *api.Config, contextName string) (*rest.Config, error) {
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	restCfg, err := clientcmd.NewDefaultClientConfig(*cfg, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("building client config for context %s: %w", contextName, err)
+	}
+	restCfg.Timeout = verifyTimeout
+	return restCfg, nil
+}
+
+// verifyContext requests the /version endpoint of the cluster behind the
+// named context using its credentials and returns the server's git version.
+func verifyContext(cfg *api.Config, contextName string) (string, error) {
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if err := kubeAPIRequest(cfg, contextName, "GET", "/version", nil, &version); err != nil {
+		return "", err
+	}
+	return version.GitVersion, nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// Set by goreleaser via -ldflags "-X main.version=... -X main.commit=...".
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+const releasesURL = "https://api.github.com/repos/mschneider82/kubeconfig-updater/releases/latest"
+
+// githubRelease is the subset of the GitHub release API response we need.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func runVersion(args []string) error {
+	v, c := currentVersion()
+	fmt.Printf("kubeconfig-updater %s (commit %s, built %s, %s/%s)\n", v, c, date, runtime.GOOS, runtime.GOARCH)
+	return nil
+}
+
+// currentVersion returns the version and commit of the running binary.
+func currentVersion() (string, string) {
+	v, c := version, commit
+	if v == "dev" {
+		// Built with go install, fall back to the module and VCS information
+		if info, ok := debug.ReadBuildInfo(); ok {
+			if info.Main.Version != "" && info.Main.Version != "(devel)" {
+				v = info.Main.Version
+			}
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					c = setting.Value
+				}
+			}
+		}
+	}
+	return v, c
+}
+
+func runSelfUpdate(args []string) error {
+	fs, _ := newFlagSet("self-update")
+	checkFlag := fs.Bool("check", false, "Only check whether a newer release exists")
+	yesFlag := fs.Bool("yes", false, "Do not ask for confirmation")
+	forceFlag := fs.Bool("force", false, "Also replace development builds and releases newer than the latest one")
+	fs.Parse(args)
+
+	var release githubRelease
+	data, err := httpGet(releasesURL, http.Header{"Accept": {"application/vnd.github+json"}})
+	if err != nil {
+		return fmt.Errorf("fetching latest release: %w", err)
+	}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return fmt.Errorf("parsing latest release: %w", err)
+	}
+	if !isVersion(release.TagName) {
+		return fmt.Errorf("latest release %s is not a release version", release.TagName)
+	}
+	current, _ := currentVersion()
+	// Fails only for the current version, which is a development build then
+	newer, err := compareVersions(release.TagName, current)
+	switch {
+	case err != nil:
+		fmt.Printf("Latest release is %s, running development build %s\n", release.TagName, current)
+		if *checkFlag {
+			return nil
+		}
+		if !*forceFlag {
+			return fmt.Errorf("not replacing development build %s, pass --force to install release %s", current, release.TagName)
+		}
+	case newer == 0:
+		infof("Already running the latest release %s\n", release.TagName)
+		return nil
+	case newer < 0:
+		fmt.Printf("Running %s, newer than the latest release %s\n", current, release.TagName)
+		if *checkFlag {
+			return nil
+		}
+		if !*forceFlag {
+			return fmt.Errorf("not downgrading %s to %s, pass --force to do so anyway", current, release.TagName)
+		}
+	default:
+		fmt.Printf("Latest release is %s, running %s\n", release.TagName, current)
+		if *checkFlag {
+			return nil
+		}
+	}
+
+	archiveName := releaseArchiveName()
+	var archiveURL, checksumsURL string
+	for _, asset := range release.Assets {
+		switch {
+		case asset.Name == archiveName:
+			archiveURL = asset.URL
+		case isChecksumsAsset(asset.Name):
+			checksumsURL = asset.URL
+		}
+	}
+	if archiveURL == "" {
+		return fmt.Errorf("release %s has no archive %s", release.TagName, archiveName)
+	}
+	if checksumsURL == "" {
+		return fmt.Errorf("release %s has no checksums file, refusing to update", release.TagName)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("locating executable: %w", err)
+	}
+	if !*yesFlag {
+		var proceed bool
+		err = runForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Replace %s with release %s?", executable, release.TagName)).
+					Value(&proceed),
+			),
+		)
+		if err != nil {
+			return fmt.Errorf("getting confirmation: %w", err)
+		}
+		if !proceed {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	archive, err := httpGet(archiveURL, nil)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", archiveName, err)
+	}
+	checksums, err := httpGet(checksumsURL, nil)
+	if err != nil {
+		return fmt.Errorf("downloading checksums: %w", err)
+	}
+	if err := verifyChecksum(archiveName, archive, checksums); err != nil {
+		return err
+	}
+	binary, err := extractBinary(archiveName, archive)
+	if err != nil {
+		return err
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		return err
+	}
+	infof("Updated %s to %s\n", executable, release.TagName)
+	return nil
+}
+
+// releaseArchiveName returns the name of the release archive for this
+// platform, following the name_template in .goreleaser.yaml.
+func releaseArchiveName() string {
+	arch := runtime.GOARCH
+	switch arch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	}
+	ext := ".tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("kubeconfig-updater_%s%s_%s%s", strings.ToUpper(runtime.GOOS[:1]), runtime.GOOS[1:], arch, ext)
+}
+
+// isChecksumsAsset reports whether name is the checksums file of a release:
+// checksums.txt, as configured in .goreleaser.yaml, or goreleaser's default
+// <project>_<version>_checksums.txt.
+func isChecksumsAsset(name string) bool {
+	return name == "checksums.txt" || strings.HasSuffix(name, "_checksums.txt")
+}
+
+// isVersion reports whether v is a release version like v1.2.3.
+func isVersion(v string) bool {
+	_, _, err := parseVersion(v)
+	return err == nil
+}
+
+// parseVersion splits a release version like v1.2.3 or 1.2.3-rc.1 into its
+// major, minor and patch numbers and its pre-release part. Build metadata
+// after a + is ignored.
+func parseVersion(v string) ([3]int, string, error) {
+	var nums [3]int
+	core := strings.TrimPrefix(v, "v")
+	if i := strings.Index(core, "+"); i >= 0 {
+		core = core[:i]
+	}
+	var pre string
+	if i := strings.Index(core, "-"); i >= 0 {
+		core, pre = core[:i], core[i+1:]
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return nums, "", fmt.Errorf("%s is not a release version", v)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nums, "", fmt.Errorf("%s is not a release version", v)
+		}
+		nums[i] = n
+	}
+	return nums, pre, nil
+}
+
+// compareVersions returns a positive number if the release version a is
+// newer than b, a negative one if it is older and 0 if they are equal,
+// following semantic versioning precedence.
+func compareVersions(a, b string) (int, error) {
+	aNums, aPre, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bNums, bPre, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range aNums {
+		if aNums[i] != bNums[i] {
+			return aNums[i] - bNums[i], nil
+		}
+	}
+	// A pre-release precedes the release itself
+	switch {
+	case aPre == bPre:
+		return 0, nil
+	case aPre == "":
+		return 1, nil
+	case bPre == "":
+		return -1, nil
+	}
+	aIDs, bIDs := strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		if c := comparePrerelease(aIDs[i], bIDs[i]); c != 0 {
+			return c, nil
+		}
+	}
+	return len(aIDs) - len(bIDs), nil
+}
+
+// comparePrerelease compares two pre-release identifiers: numerically if
+// both are numbers, which precede alphanumeric ones, and else as strings.
+func comparePrerelease(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return an - bn
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// verifyChecksum checks data against the sha256 sum listed for name in
+// a goreleaser checksums file. The checksums file is not signed, so this
+// catches corrupted downloads but not a tampered release.
+func verifyChecksum(name string, data, checksums []byte) error {
+	sum := sha256.Sum256(data)
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			if fields[0] != hex.EncodeToString(sum[:]) {
+				return fmt.Errorf("checksum mismatch for %s", name)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no checksum listed for %s", name)
+}
+
+// extractBinary returns the executable contained in the release archive.
+func extractBinary(name string, archive []byte) ([]byte, error) {
+	binaryName := "kubeconfig-updater"
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) == binaryName {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+		return nil, fmt.Errorf("%s not found in %s", binaryName, name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in %s", binaryName, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == binaryName {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable atomically replaces the file at path with binary. The
+// running executable is moved aside first, which Windows requires.
+func replaceExecutable(path string, binary []byte) error {
+	newPath, oldPath := path+".new", path+".old"
+	if err := ioutil.WriteFile(newPath, binary, 0o755); err != nil {
+		return fmt.Errorf("writing new executable: %w", err)
+	}
+	os.Remove(oldPath)
+	if err := os.Rename(path, oldPath); err != nil {
+		os.Remove(newPath)
+		return fmt.Errorf("moving old executable aside: %w", err)
+	}
+	if err := os.Rename(newPath, path); err != nil {
+		os.Rename(oldPath, path)
+		return fmt.Errorf("installing new executable: %w", err)
+	}
+	// Removing fails on Windows while the old binary is running
+	os.Remove(oldPath)
+	return nil
+}
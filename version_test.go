@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.2.3", "v1.2.3-rc.1", 1},
+		{"v1.2.3-rc.2", "v1.2.3-rc.10", -1},
+		{"v1.2.3-rc.1", "v1.2.3-beta", 1},
+		{"v1.2.3-rc", "v1.2.3-rc.1", -1},
+		{"v1.2.3+build.5", "v1.2.3", 0},
+	}
+	for _, tt := range tests {
+		got, err := compareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("compareVersions(%s, %s): %v", tt.a, tt.b, err)
+		}
+		if sign(got) != tt.want {
+			t.Errorf("compareVersions(%s, %s) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsDevelopmentBuild(t *testing.T) {
+	for _, v := range []string{"dev", "(devel)", "v1.2", "v1.2.3.4", "v1.x.3"} {
+		if _, err := compareVersions("v1.2.3", v); err == nil {
+			t.Errorf("compareVersions accepted %s", v)
+		}
+	}
+}
+
+func TestIsChecksumsAsset(t *testing.T) {
+	for name, want := range map[string]bool{
+		"checksums.txt":                          true,
+		"kubeconfig-updater_1.2.3_checksums.txt": true,
+		"kubeconfig-updater_Linux_x86_64.tar.gz": false,
+		"checksums.txt.sig":                      false,
+	} {
+		if got := isChecksumsAsset(name); got != want {
+			t.Errorf("isChecksumsAsset(%s) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fsnotify/fsnotify"
+	"github.com/mschneider82/kubeconfig-updater/pkg/kubeconfig"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// watchSettle is how long a file must stay unchanged before it is looked
+// at, so downloads still being written are not picked up half way.
+const watchSettle = time.Second
+
+// maxWatchedFileSize skips files that are too large to be a kubeconfig.
+const maxWatchedFileSize = 1 << 20
+
+func runWatch(args []string) error {
+	fs, configPathFlag := newFlagSet("watch")
+	autoFlag := fs.Bool("auto", false, "Merge new kubeconfigs without asking")
+	addStrategyFlag(fs)
+	addRenameSuffixFlag(fs)
+	addNotifyFlag(fs)
+	archiveFlag := fs.String("archive", "~/.kube/imported", "Move merged kubeconfig files into this directory")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one directory to watch")
+	}
+	dir, err := expandPath(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	configPath, err := expandPath(*configPathFlag)
+	if err != nil {
+		return err
+	}
+	archiveDir, err := expandPath(*archiveFlag)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("watching %s: %w", dir, err)
+	}
+	infof("Watching %s for new kubeconfig files (ctrl+c to stop)\n", dir)
+
+	pending := map[string]time.Time{}
+	ticker := time.NewTicker(watchSettle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				debugf("%s: %s", event.Op, event.Name)
+				pending[event.Name] = time.Now()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Warning: watching %s: %v\n", dir, err)
+		case now := <-ticker.C:
+			for path, changed := range pending {
+				if now.Sub(changed) < watchSettle {
+					continue
+				}
+				delete(pending, path)
+				if err := handleWatchedFile(path, configPath, archiveDir, *autoFlag); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+				}
+			}
+		}
+	}
+}
+
+// handleWatchedFile merges the file at path into the kubeconfig at
+// configPath if it is a kubeconfig, after asking unless auto is set, and
+// moves it into archiveDir afterwards.
+func handleWatchedFile(path, configPath, archiveDir string, auto bool) error {
+	incoming, ok := readDroppedKubeconfig(path)
+	if !ok {
+		return nil
+	}
+	infof("\nFound kubeconfig %s\n", path)
+
+	origData, cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	changes, err := dedupeClusters(cfg, incoming)
+	if err != nil {
+		return err
+	}
+	renameChanges, err := renameCollisions(cfg, incoming)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, renameChanges...)
+	mergeChanges, err := kubeconfig.Merge(cfg, incoming, resolveConflict)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, mergeChanges...)
+	printChanges(changes)
+	if len(changes) > 0 {
+		if !auto {
+			var proceed bool
+			err = runForm(
+				huh.NewGroup(
+					huh.NewConfirm().
+						Title(fmt.Sprintf("Merge %s into %s?", filepath.Base(path), configPath)).
+						Value(&proceed),
+				),
+			)
+			if err != nil {
+				return fmt.Errorf("getting confirmation: %w", err)
+			}
+			if !proceed {
+				infof("Skipped.\n")
+				return nil
+			}
+		}
+		audit.source = "watch " + path
+		if err := saveConfig(configPath, origData, cfg, changes); err != nil {
+			return err
+		}
+	}
+
+	archived, err := archiveFile(path, archiveDir)
+	if err != nil {
+		return fmt.Errorf("archiving: %w", err)
+	}
+	infof("Moved %s to %s\n", path, archived)
+	return nil
+}
+
+// readDroppedKubeconfig parses the file at path as kubeconfig. It reports
+// false for directories, partial downloads and anything that is not a
+// kubeconfig with at least one context.
+func readDroppedKubeconfig(path string) (*api.Config, bool) {
+	for _, suffix := range []string{".crdownload", ".part", ".download", ".tmp"} {
+		if strings.HasSuffix(path, suffix) {
+			return nil, false
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxWatchedFileSize {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	if data, err = decodeInput(data, false); err != nil {
+		return nil, false
+	}
+	cfg, err := clientcmd.Load(data)
+	if err != nil || len(cfg.Contexts) == 0 {
+		debugf("%s is not a kubeconfig", path)
+		return nil, false
+	}
+	return cfg, true
+}
+
+// archiveFile moves the file at path into dir, prefixing its name with a
+// timestamp, and returns the new path.
+func archiveFile(path, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, time.Now().Format("20060102-150405-")+filepath.Base(path))
+	if err := os.Rename(path, target); err == nil {
+		return target, nil
+	}
+	// Rename fails across file systems, fall back to copying
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return target, os.Remove(path)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/user"
+)
+
+// webhookPayload is the JSON body POSTed to the webhook: the audit log entry
+// of the update plus who made it where.
+type webhookPayload struct {
+	auditEntry
+	User string `json:"user,omitempty"`
+	Host string `json:"host,omitempty"`
+}
+
+// notifyWebhook POSTs the changes applied to the kubeconfig at configPath to
+// the webhook of the settings file, if one is configured. Like the audit
+// log, a failure only produces a warning.
+func notifyWebhook(configPath string, changes []string) {
+	if userSettings.Webhook == "" || len(changes) == 0 {
+		return
+	}
+	payload := webhookPayload{auditEntry: newAuditEntry(configPath, changes)}
+	if u, err := user.Current(); err == nil {
+		payload.User = u.Username
+	}
+	payload.Host, _ = os.Hostname()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: encoding webhook payload: %v\n", err)
+		return
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	for key, value := range userSettings.WebhookHeaders {
+		header.Set(key, os.ExpandEnv(value))
+	}
+	debugf("posting %d changes to webhook %s", len(changes), userSettings.Webhook)
+	if _, err := httpDo("POST", userSettings.Webhook, header, bytes.NewReader(body)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: notifying webhook: %v\n", err)
+	}
+}